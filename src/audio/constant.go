@@ -0,0 +1,81 @@
+package audio
+
+const (
+	// DurationTolerance : max tolerated difference between decoded and expected durations
+	DurationTolerance = 20 // second(s)
+	// DecodeErrorsTolerance : max tolerated number of decoding errors
+	DecodeErrorsTolerance = 5
+	// SilenceThreshold : max volume under which audio is considered silent
+	SilenceThreshold = -50.0 // dB
+
+	// AlignmentSampleRate : sample rate audio gets decoded to in order to be aligned
+	AlignmentSampleRate = 4000 // Hz
+	// AlignmentConfidence : min normalized cross-correlation peak for an alignment to be trusted
+	AlignmentConfidence = 0.4
+	// AlignmentTrimTolerance : min exceeding audio worth trimming
+	AlignmentTrimTolerance = 1.0 // second(s)
+
+	// FormatMP3 : mp3 output format name
+	FormatMP3 = "mp3"
+	// FormatFLAC : FLAC output format name
+	FormatFLAC = "flac"
+	// FormatOpus : Opus output format name
+	FormatOpus = "opus"
+	// FormatM4A : M4A/AAC output format name
+	FormatM4A = "m4a"
+	// FormatOgg : Ogg Vorbis output format name
+	FormatOgg = "ogg"
+
+	// EncodingCodec : default codec songs get encoded with
+	EncodingCodec = "libmp3lame"
+	// EncodingBitrate : default bitrate songs get encoded at
+	EncodingBitrate = "320k"
+
+	// SilenceMinDuration : default minimum length of leading or trailing silence to be trimmed
+	SilenceMinDuration = 0.5 // seconds
+	// SilenceEdgeTolerance : max distance from song edges for a silence to be considered leading or trailing
+	SilenceEdgeTolerance = 0.05 // seconds
+
+	// NormalizationPeak : normalization mode raising peak volume to 0 dB, re-encoding
+	NormalizationPeak = "peak"
+	// NormalizationReplayGain : normalization mode measuring loudness and tagging ReplayGain values, without altering audio
+	NormalizationReplayGain = "replaygain"
+	// NormalizationLoudnorm : normalization mode applying two-pass EBU R128 loudness normalization, re-encoding
+	NormalizationLoudnorm = "loudnorm"
+	// ReplayGainReference : ReplayGain 2.0 reference loudness
+	ReplayGainReference = -18.0 // LUFS
+	// LoudnormIntegrated : loudnorm target integrated loudness
+	LoudnormIntegrated = -16.0 // LUFS
+	// LoudnormTruePeak : loudnorm target max true peak
+	LoudnormTruePeak = -1.5 // dBTP
+	// LoudnormRange : loudnorm target loudness range
+	LoudnormRange = 11.0 // LU
+	// LoudnormSampleRate : sample rate loudnorm output gets resampled to, when not explicitly set
+	LoudnormSampleRate = 48000 // Hz
+
+	// FingerprintLength : max audio duration getting fingerprinted
+	FingerprintLength = 1200 // second(s)
+	// FingerprintAccept : min fingerprints similarity for a match to be accepted
+	FingerprintAccept = 0.8
+	// FingerprintReject : fingerprints similarity under which a match gets rejected
+	FingerprintReject = 0.65
+
+	// QualitySampleRate : sample rate audio gets decoded to in order to estimate its quality
+	QualitySampleRate = 44100 // Hz
+	// QualitySegmentOffset : offset of the audio segment getting analyzed to estimate its quality
+	QualitySegmentOffset = 30 // second(s)
+	// QualitySegmentDuration : duration of the audio segment getting analyzed to estimate its quality
+	QualitySegmentDuration = 30 // second(s)
+	// QualityFrameSize : number of samples per FFT frame
+	QualityFrameSize = 4096
+	// QualityBandWidth : width of the frequency bands spectrum gets averaged into
+	QualityBandWidth = 250.0 // Hz
+	// QualityCliff : min level drop for a frequency band to be considered an encoder lowpass cutoff
+	QualityCliff = 20.0 // dB
+	// QualitySlope : max level drop between adjacent frequency bands still considered passband
+	QualitySlope = 6.0 // dB
+	// QualityReportSize : number of worst quality songs getting reported
+	QualityReportSize = 20
+	// QualityFloor : min level a frequency band is considered at
+	QualityFloor = -150.0 // dB
+)
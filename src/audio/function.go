@@ -0,0 +1,248 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+func decode(path string, sampleRate int, from float64, duration float64) ([]float64, error) {
+	var (
+		commandOut    bytes.Buffer
+		commandErrOut bytes.Buffer
+		commandCmd    = "ffmpeg"
+		commandArgs   = []string{"-nostdin"}
+	)
+	if from > 0 {
+		commandArgs = append(commandArgs, "-ss", fmt.Sprintf("%.3f", from))
+	}
+	if duration > 0 {
+		commandArgs = append(commandArgs, "-t", fmt.Sprintf("%.3f", duration))
+	}
+	commandArgs = append(commandArgs, "-i", path, "-vn", "-ac", "1", "-ar", fmt.Sprintf("%d", sampleRate), "-f", "s16le", "-")
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stdout = &commandOut
+	commandObj.Stderr = &commandErrOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandErrOut.String()))
+	}
+
+	samples := make([]float64, commandOut.Len()/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(commandOut.Bytes()[i*2:]))) / 32768
+	}
+	return samples, nil
+}
+
+func bandLevels(samples []float64, bandBins int) []float64 {
+	var (
+		spectrum = make([]float64, QualityFrameSize/2)
+		frame    = make([]complex128, QualityFrameSize)
+		frames   int
+	)
+	for start := 0; start+QualityFrameSize <= len(samples); start += QualityFrameSize {
+		for i := range frame {
+			window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(QualityFrameSize-1))
+			frame[i] = complex(samples[start+i]*window, 0)
+		}
+		fft(frame, false)
+		for i := range spectrum {
+			spectrum[i] += real(frame[i])*real(frame[i]) + imag(frame[i])*imag(frame[i])
+		}
+		frames++
+	}
+
+	if bandBins < 1 {
+		bandBins = 1
+	}
+	levels := make([]float64, len(spectrum)/bandBins)
+	for band := range levels {
+		var power float64
+		for _, binPower := range spectrum[band*bandBins : (band+1)*bandBins] {
+			power += binPower
+		}
+		levels[band] = QualityFloor
+		if power > 0 && frames > 0 {
+			levels[band] = math.Max(QualityFloor, 10*math.Log10(power/float64(frames*bandBins)))
+		}
+	}
+	return levels
+}
+
+func parseProbe(output string) Probe {
+	var probe Probe
+	for _, line := range strings.FieldsFunc(output, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if strings.Contains(line, "[error]") || strings.Contains(line, "[fatal]") {
+			probe.Errors = append(probe.Errors, strings.TrimSpace(line))
+		}
+		if match := probeTimePattern.FindStringSubmatch(line); len(match) > 0 {
+			hours, _ := strconv.ParseFloat(match[1], 64)
+			minutes, _ := strconv.ParseFloat(match[2], 64)
+			seconds, _ := strconv.ParseFloat(match[3], 64)
+			probe.Duration = hours*3600 + minutes*60 + seconds
+		}
+		if match := probeMaxVolumePattern.FindStringSubmatch(line); len(match) > 0 {
+			if maxVolume, maxVolumeErr := strconv.ParseFloat(match[1], 64); maxVolumeErr == nil {
+				probe.MaxVolume = maxVolume
+			}
+		}
+	}
+	return probe
+}
+
+func fft(values []complex128, inverse bool) {
+	var n = len(values)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		angle := 2 * math.Pi / float64(size)
+		if !inverse {
+			angle = -angle
+		}
+		step := cmplx.Rect(1, angle)
+		for start := 0; start < n; start += size {
+			twiddle := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				even, odd := values[start+k], values[start+k+size/2]*twiddle
+				values[start+k], values[start+k+size/2] = even+odd, even-odd
+				twiddle *= step
+			}
+		}
+	}
+	if inverse {
+		for i := range values {
+			values[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	power := 1
+	for power < n {
+		power <<= 1
+	}
+	return power
+}
+
+func crossCorrelate(samples []float64, pattern []float64) []float64 {
+	var (
+		size            = nextPowerOfTwo(len(samples) + len(pattern))
+		samplesSpectrum = make([]complex128, size)
+		patternSpectrum = make([]complex128, size)
+	)
+	for i, sample := range samples {
+		samplesSpectrum[i] = complex(sample, 0)
+	}
+	for i, sample := range pattern {
+		patternSpectrum[i] = complex(sample, 0)
+	}
+	fft(samplesSpectrum, false)
+	fft(patternSpectrum, false)
+	for i := range samplesSpectrum {
+		samplesSpectrum[i] *= cmplx.Conj(patternSpectrum[i])
+	}
+	fft(samplesSpectrum, true)
+
+	correlation := make([]float64, len(samples)-len(pattern)+1)
+	for i := range correlation {
+		correlation[i] = real(samplesSpectrum[i])
+	}
+	return correlation
+}
+
+func parseFingerprint(output string) (Fingerprint, error) {
+	var fingerprint Fingerprint
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "FINGERPRINT=") {
+			continue
+		}
+		for _, value := range strings.Split(strings.TrimSpace(strings.TrimPrefix(line, "FINGERPRINT=")), ",") {
+			item, itemErr := strconv.ParseInt(value, 10, 64)
+			if itemErr != nil {
+				return nil, fmt.Errorf(fmt.Sprintf("Unable to parse fingerprint item \"%s\": %s", value, itemErr.Error()))
+			}
+			fingerprint = append(fingerprint, uint32(item))
+		}
+	}
+	if len(fingerprint) == 0 {
+		return nil, fmt.Errorf("No fingerprint found")
+	}
+	return fingerprint, nil
+}
+
+func bitsSimilarity(fingerprint Fingerprint, other Fingerprint) float64 {
+	var matchingBits int
+	for i := range fingerprint {
+		matchingBits += 32 - bits.OnesCount32(fingerprint[i]^other[i])
+	}
+	return float64(matchingBits) / float64(32*len(fingerprint))
+}
+
+func parseLoudness(output string) (Loudness, error) {
+	var (
+		loudness     Loudness
+		loudnessJSON map[string]string
+	)
+	if strings.LastIndex(output, "{") < 0 || strings.LastIndex(output, "}") < strings.LastIndex(output, "{") {
+		return loudness, fmt.Errorf("No loudness measurement found")
+	}
+	output = output[strings.LastIndex(output, "{") : strings.LastIndex(output, "}")+1]
+	if err := json.Unmarshal([]byte(output), &loudnessJSON); err != nil {
+		return loudness, fmt.Errorf(fmt.Sprintf("Unable to parse loudness measurement: %s", err.Error()))
+	}
+	for key, value := range map[string]*float64{
+		"input_i":       &loudness.Integrated,
+		"input_tp":      &loudness.TruePeak,
+		"input_lra":     &loudness.Range,
+		"input_thresh":  &loudness.Threshold,
+		"target_offset": &loudness.Offset,
+	} {
+		parsed, parsedErr := strconv.ParseFloat(loudnessJSON[key], 64)
+		if parsedErr != nil {
+			return loudness, fmt.Errorf(fmt.Sprintf("Unable to parse loudness measurement \"%s\": %s", key, parsedErr.Error()))
+		}
+		*value = parsed
+	}
+	return loudness, nil
+}
+
+func parseSilence(output string) Silence {
+	var (
+		silence      = Silence{Duration: parseProbe(output).Duration}
+		silenceStart = -1.0
+	)
+	for _, line := range strings.FieldsFunc(output, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		if match := silenceStartPattern.FindStringSubmatch(line); len(match) > 0 {
+			silenceStart, _ = strconv.ParseFloat(match[1], 64)
+		}
+		if match := silenceEndPattern.FindStringSubmatch(line); len(match) > 0 && silenceStart >= -SilenceEdgeTolerance {
+			silenceEnd, _ := strconv.ParseFloat(match[1], 64)
+			if silenceStart <= SilenceEdgeTolerance {
+				silence.Leading = silenceEnd
+			}
+			if silence.Duration > 0 && silenceEnd >= silence.Duration-SilenceEdgeTolerance {
+				silence.Trailing = silence.Duration - silenceStart
+			}
+			silenceStart = -1
+		}
+	}
+	if silenceStart >= 0 && silence.Duration > silenceStart {
+		silence.Trailing = silence.Duration - silenceStart
+	}
+	return silence
+}
@@ -0,0 +1,114 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseProbe(t *testing.T) {
+	var output = "[info] Input #0, mp3, from 'song.mp3':\n" +
+		"[info]   Duration: 00:03:21.16, start: 0.025057, bitrate: 320 kb/s\n" +
+		"[mp3float @ 0x55d0c] [error] Header missing\n" +
+		"[info] size=N/A time=00:01:10.00 bitrate=N/A speed= 140x\r" +
+		"[info] size=N/A time=00:03:21.12 bitrate=N/A speed= 402x\n" +
+		"[Parsed_volumedetect_0 @ 0x55d0d] [info] n_samples: 17744896\n" +
+		"[Parsed_volumedetect_0 @ 0x55d0d] [info] max_volume: -0.4 dB\n"
+	probe := parseProbe(output)
+	if math.Abs(probe.Duration-201.12) > 0.001 {
+		t.Errorf("parsed duration = %f, expected 201.12", probe.Duration)
+	}
+	if len(probe.Errors) != 1 {
+		t.Errorf("parsed errors = %v, expected one", probe.Errors)
+	}
+	if probe.MaxVolume != -0.4 {
+		t.Errorf("parsed max volume = %f, expected -0.4", probe.MaxVolume)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	for _, fixture := range []struct {
+		probe    Probe
+		duration int
+		valid    bool
+	}{
+		{Probe{Duration: 201.12, MaxVolume: -0.4}, 200, true},
+		{Probe{Duration: 201.12, MaxVolume: -0.4}, 0, true},
+		{Probe{Duration: 62.5, MaxVolume: -0.4}, 200, false},
+		{Probe{Duration: 612.0, MaxVolume: -0.4}, 200, false},
+		{Probe{Duration: 201.12, MaxVolume: math.Inf(-1)}, 200, false},
+		{Probe{Duration: 201.12, MaxVolume: -0.4, Errors: []string{"a", "b", "c", "d", "e", "f"}}, 200, false},
+	} {
+		if err := fixture.probe.Verify(fixture.duration); fixture.valid && err != nil {
+			t.Errorf("%s expected to be valid for %d seconds: %s", fixture.probe.String(), fixture.duration, err.Error())
+		} else if !fixture.valid && err == nil {
+			t.Errorf("%s expected not to be valid for %d seconds", fixture.probe.String(), fixture.duration)
+		}
+	}
+}
+
+func TestFFT(t *testing.T) {
+	values := []complex128{1, 2, 3, 4, 0, 0, 0, 0}
+	fft(values, false)
+	fft(values, true)
+	for i, expected := range []float64{1, 2, 3, 4, 0, 0, 0, 0} {
+		if math.Abs(real(values[i])-expected) > 1e-9 || math.Abs(imag(values[i])) > 1e-9 {
+			t.Errorf("fft round trip = %v, expected %v at %d", values[i], expected, i)
+		}
+	}
+}
+
+func TestCrossCorrelate(t *testing.T) {
+	correlation := crossCorrelate([]float64{0, 0, 1, 2, 3, 0, 0}, []float64{1, 2, 3})
+	expected := []float64{3, 8, 14, 8, 3}
+	for i := range expected {
+		if math.Abs(correlation[i]-expected[i]) > 1e-9 {
+			t.Errorf("correlation = %v, expected %v", correlation, expected)
+			break
+		}
+	}
+}
+
+func TestParseFingerprint(t *testing.T) {
+	fingerprint, err := parseFingerprint("DURATION=201\nFINGERPRINT=-1524164102,2622800378,2606023162\n")
+	if err != nil {
+		t.Fatalf("fingerprint expected to be parsed: %s", err.Error())
+	}
+	if len(fingerprint) != 3 || fingerprint[0] != uint32(2770803194) || fingerprint[1] != 2622800378 {
+		t.Errorf("parsed fingerprint = %v", fingerprint)
+	}
+	if _, err := parseFingerprint("DURATION=201\n"); err == nil {
+		t.Errorf("missing fingerprint expected to fail")
+	}
+}
+
+func TestParseLoudness(t *testing.T) {
+	var output = "[Parsed_loudnorm_0 @ 0x5581] \n{\n" +
+		"\t\"input_i\" : \"-9.83\",\n\t\"input_tp\" : \"0.41\",\n\t\"input_lra\" : \"5.30\",\n\t\"input_thresh\" : \"-19.95\",\n" +
+		"\t\"output_i\" : \"-16.02\",\n\t\"output_tp\" : \"-1.50\",\n\t\"output_lra\" : \"4.60\",\n\t\"output_thresh\" : \"-26.10\",\n" +
+		"\t\"normalization_type\" : \"dynamic\",\n\t\"target_offset\" : \"0.02\"\n}\n"
+	loudness, err := parseLoudness(output)
+	if err != nil {
+		t.Fatalf("loudness expected to be parsed: %s", err.Error())
+	}
+	if loudness != (Loudness{Integrated: -9.83, TruePeak: 0.41, Range: 5.3, Threshold: -19.95, Offset: 0.02}) {
+		t.Errorf("parsed loudness = %+v", loudness)
+	}
+	if _, err := parseLoudness("size=N/A time=00:03:21.12"); err == nil {
+		t.Errorf("missing loudness expected to fail")
+	}
+}
+
+func TestParseSilence(t *testing.T) {
+	var output = "[silencedetect @ 0x55] silence_start: 0\n" +
+		"[silencedetect @ 0x55] silence_end: 4.21 | silence_duration: 4.21\n" +
+		"[silencedetect @ 0x55] silence_start: 100.5\n" +
+		"[silencedetect @ 0x55] silence_end: 101.2 | silence_duration: 0.7\n" +
+		"[silencedetect @ 0x55] silence_start: 203.1\n" +
+		"size=N/A time=00:03:30.00 bitrate=N/A speed= 512x\n"
+	if silence := parseSilence(output); silence.Leading != 4.21 || math.Abs(silence.Trailing-6.9) > 0.001 || silence.Duration != 210 {
+		t.Errorf("parsed silence = %+v", silence)
+	}
+	if silence := parseSilence("size=N/A time=00:03:30.00 bitrate=N/A\n"); silence.Leading != 0 || silence.Trailing != 0 {
+		t.Errorf("parsed silence = %+v, expected none", silence)
+	}
+}
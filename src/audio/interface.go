@@ -0,0 +1,392 @@
+package audio
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	spttb_system "system"
+)
+
+// Analyze : fully decode input audio file, measuring its real duration, decoding errors and max volume
+func Analyze(path string) (Probe, error) {
+	var commandOut bytes.Buffer
+	commandCmd := "ffmpeg"
+	commandArgs := []string{"-hide_banner", "-nostdin", "-loglevel", "level+info", "-i", path, "-map", "0:a:0", "-af", "volumedetect", "-f", "null", "-"}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stderr = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return Probe{}, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
+	}
+	return parseProbe(commandOut.String()), nil
+}
+
+// Duration : return input audio file duration, in seconds, as declared by its container, without decoding it
+func Duration(path string) (float64, error) {
+	commandCmd := "ffprobe"
+	commandArgs := []string{"-v", "error", "-show_entries", "format=duration", "-of", "default=noprint_wrappers=1:nokey=1", path}
+	commandOut, commandErr := exec.Command(commandCmd, commandArgs...).Output()
+	if commandErr != nil {
+		return 0, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\": %s", commandCmd, strings.Join(commandArgs, " "), commandErr.Error()))
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(commandOut)), 64)
+}
+
+// Verify : return nil error if Probe is consistent with input expected duration, in seconds
+func (probe Probe) Verify(duration int) error {
+	if probe.Corrupted() {
+		return fmt.Errorf(fmt.Sprintf("Decoding raised %d errors (max tolerated: %d), such as: %s",
+			len(probe.Errors), DecodeErrorsTolerance, probe.Errors[0]))
+	}
+	if probe.Duration == 0 {
+		return fmt.Errorf("Decoded audio seems to be empty")
+	}
+	if probe.MaxVolume <= SilenceThreshold {
+		return fmt.Errorf(fmt.Sprintf("Decoded audio seems to be silent (max volume: %.1f dB)", probe.MaxVolume))
+	}
+	if duration > 0 && math.Abs(probe.Duration-float64(duration)) > DurationTolerance {
+		return fmt.Errorf(fmt.Sprintf("The decoded duration difference is excessive: | %d - %.0f | = %.0f (max tolerated: %d)",
+			duration, probe.Duration, math.Abs(probe.Duration-float64(duration)), DurationTolerance))
+	}
+	return nil
+}
+
+// Corrupted : return True if Probe decoding errors exceed the tolerated ones
+func (probe Probe) Corrupted() bool {
+	return len(probe.Errors) > DecodeErrorsTolerance
+}
+
+// String : return Probe summary, as it gets stored into tags
+func (probe Probe) String() string {
+	return fmt.Sprintf("duration=%.2f errors=%d max_volume=%.1f", probe.Duration, len(probe.Errors), probe.MaxVolume)
+}
+
+// Decode : decode input audio file or URL into mono samples, at AlignmentSampleRate
+func Decode(path string) ([]float64, error) {
+	return decode(path, AlignmentSampleRate, 0, 0)
+}
+
+// Align : locate input preview samples inside input samples, via normalized cross-correlation
+func Align(samples []float64, preview []float64) Alignment {
+	if len(preview) == 0 || len(samples) < len(preview) {
+		return Alignment{}
+	}
+
+	var previewEnergy float64
+	for _, sample := range preview {
+		previewEnergy += sample * sample
+	}
+	samplesEnergy := make([]float64, len(samples)+1)
+	for i, sample := range samples {
+		samplesEnergy[i+1] = samplesEnergy[i] + sample*sample
+	}
+
+	var alignment Alignment
+	for offset, correlation := range crossCorrelate(samples, preview) {
+		windowEnergy := samplesEnergy[offset+len(preview)] - samplesEnergy[offset]
+		if windowEnergy <= 0 || previewEnergy <= 0 {
+			continue
+		}
+		if confidence := correlation / math.Sqrt(windowEnergy*previewEnergy); confidence > alignment.Confidence {
+			alignment.Offset = float64(offset) / AlignmentSampleRate
+			alignment.Confidence = confidence
+		}
+	}
+	return alignment
+}
+
+// Trusted : return True if Alignment confidence is high enough to rely on it
+func (alignment Alignment) Trusted() bool {
+	return alignment.Confidence >= AlignmentConfidence
+}
+
+// Window : return the portion of an audio of input duration, which the aligned preview of input
+// duration has been found into, that can hold a song of input expected duration: as the preview
+// position inside the original song is unknown, only audio that cannot belong to it gets excluded
+func (alignment Alignment) Window(duration float64, previewDuration float64, expected float64) (float64, float64) {
+	var (
+		startMin = math.Max(0, alignment.Offset-(expected-previewDuration))
+		startMax = math.Min(alignment.Offset, math.Max(0, duration-expected))
+	)
+	if startMax < startMin {
+		startMax = startMin
+	}
+	return startMin, math.Min(duration, startMax+expected)
+}
+
+// DetectSilence : detect leading and trailing silence of input audio file, via ffmpeg silencedetect,
+// considering silent any portion lower than input threshold, in dB, lasting at least input duration, in seconds
+func DetectSilence(path string, threshold float64, duration float64) (Silence, error) {
+	var commandOut bytes.Buffer
+	commandCmd := "ffmpeg"
+	commandArgs := []string{"-hide_banner", "-nostdin", "-i", path, "-map", "0:a:0", "-af",
+		fmt.Sprintf("silencedetect=noise=%.1fdB:duration=%.2f", threshold, duration), "-f", "null", "-"}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stderr = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return Silence{}, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
+	}
+	return parseSilence(commandOut.String()), nil
+}
+
+// Window : narrow input [from, to] boundaries, in seconds, dropping leading and trailing silence,
+// never cutting more than the exceeding length compared to input expected duration, in seconds
+func (silence Silence) Window(from float64, to float64, expected float64) (float64, float64) {
+	if to <= 0 || to > silence.Duration {
+		to = silence.Duration
+	}
+	var exceeding = to - from - expected
+	if exceeding <= 0 {
+		return from, to
+	}
+	leading := math.Min(math.Max(0, silence.Leading-from), exceeding)
+	trailing := math.Min(math.Max(0, to-(silence.Duration-silence.Trailing)), exceeding-leading)
+	return from + leading, to - trailing
+}
+
+// Encode : encode input audio into output file, applying input filters and keeping only the portion
+// between input boundaries, in seconds, if any, in a single ffmpeg invocation
+func Encode(input string, output string, encoding Encoding, filters []string, from float64, to float64) error {
+	var (
+		commandOut  bytes.Buffer
+		commandCmd  = "ffmpeg"
+		commandArgs = []string{"-nostdin", "-i", input}
+	)
+	if from > 0 {
+		commandArgs = append(commandArgs, "-ss", fmt.Sprintf("%.3f", from))
+	}
+	if to > 0 {
+		commandArgs = append(commandArgs, "-to", fmt.Sprintf("%.3f", to))
+	}
+	commandArgs = append(commandArgs, "-map", "0:a:0", "-map_metadata", "-1")
+	if len(filters) > 0 {
+		commandArgs = append(commandArgs, "-af", strings.Join(filters, ","))
+	}
+	commandArgs = append(append(commandArgs, encoding.Args()...), "-y", output)
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stderr = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		os.Remove(output)
+		return fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
+	}
+	return nil
+}
+
+// Args : return ffmpeg output arguments matching Encoding
+func (encoding Encoding) Args() []string {
+	var args = []string{"-c:a", encoding.Codec}
+	if encoding.VBR >= 0 {
+		args = append(args, "-q:a", fmt.Sprintf("%d", encoding.VBR))
+	} else if len(encoding.Bitrate) > 0 {
+		args = append(args, "-b:a", encoding.Bitrate)
+	}
+	if encoding.SampleRate > 0 {
+		args = append(args, "-ar", fmt.Sprintf("%d", encoding.SampleRate))
+	}
+	return args
+}
+
+// String : return Alignment summary, as it gets stored into tags
+func (alignment Alignment) String() string {
+	return fmt.Sprintf("preview_offset=%.2f preview_confidence=%.2f", alignment.Offset, alignment.Confidence)
+}
+
+// FingerprintOf : compute Chromaprint fingerprint of input audio file or URL, via fpcalc
+func FingerprintOf(path string) (Fingerprint, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		localPath, localErr := fetch(path)
+		if localErr != nil {
+			return nil, localErr
+		}
+		defer os.Remove(localPath)
+		path = localPath
+	}
+
+	var commandOut bytes.Buffer
+	commandCmd := "fpcalc"
+	commandArgs := []string{"-raw", "-length", fmt.Sprintf("%d", FingerprintLength), path}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stdout = &commandOut
+	commandObj.Stderr = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
+	}
+	return parseFingerprint(commandOut.String())
+}
+
+// Similarity : return the best bitwise similarity, in [0, 1], found sliding the shortest
+// between Fingerprint and input one over the other
+func (fingerprint Fingerprint) Similarity(other Fingerprint) float64 {
+	if len(fingerprint) > len(other) {
+		fingerprint, other = other, fingerprint
+	}
+	var similarity float64
+	for offset := 0; offset+len(fingerprint) <= len(other) && len(fingerprint) > 0; offset++ {
+		similarity = math.Max(similarity, bitsSimilarity(fingerprint, other[offset:offset+len(fingerprint)]))
+	}
+	return similarity
+}
+
+func fetch(url string) (string, error) {
+	client := http.Client{
+		Timeout: time.Second * spttb_system.HTTPTimeout * 10,
+	}
+	response, responseErr := client.Get(url)
+	if responseErr != nil {
+		return "", fmt.Errorf(fmt.Sprintf("Unable to fetch %s: %s", url, responseErr.Error()))
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(fmt.Sprintf("Unable to fetch %s: %s", url, response.Status))
+	}
+
+	file, fileErr := ioutil.TempFile("", "spotitube-*")
+	if fileErr != nil {
+		return "", fileErr
+	}
+	defer file.Close()
+	if _, copyErr := io.Copy(file, response.Body); copyErr != nil {
+		os.Remove(file.Name())
+		return "", copyErr
+	}
+	return file.Name(), nil
+}
+
+// EstimateQuality : estimate input audio file real quality, looking for the encoder lowpass cutoff in its spectrum
+func EstimateQuality(path string) (Quality, error) {
+	samples, samplesErr := decode(path, QualitySampleRate, QualitySegmentOffset, QualitySegmentDuration)
+	if samplesErr == nil && len(samples) < QualityFrameSize {
+		samples, samplesErr = decode(path, QualitySampleRate, 0, QualitySegmentDuration)
+	}
+	if samplesErr != nil {
+		return Quality{}, samplesErr
+	}
+	if len(samples) < QualityFrameSize {
+		return Quality{}, fmt.Errorf("Audio is too short to estimate its quality")
+	}
+	return QualityFromCutoff(Cutoff(samples, QualitySampleRate)), nil
+}
+
+// Cutoff : return the frequency input samples spectrum sharply drops at, or the Nyquist one if no drop is found:
+// the cutoff band has to be still part of the passband, while every band past the next one has to be way quieter
+func Cutoff(samples []float64, sampleRate int) float64 {
+	var (
+		bandBins = int(QualityBandWidth * QualityFrameSize / float64(sampleRate))
+		levels   = bandLevels(samples, bandBins)
+	)
+	for band := len(levels) - 3; band >= 0; band-- {
+		if levels[band]-levels[band+2] < QualityCliff || (band > 0 && levels[band] < levels[band-1]-QualitySlope) {
+			continue
+		}
+		var exceeding bool
+		for _, level := range levels[band+2:] {
+			if level > levels[band]-QualityCliff {
+				exceeding = true
+				break
+			}
+		}
+		if !exceeding {
+			return float64((band+1)*bandBins) * float64(sampleRate) / QualityFrameSize
+		}
+	}
+	return float64(sampleRate) / 2
+}
+
+// QualityFromCutoff : return Quality matching input cutoff frequency
+func QualityFromCutoff(cutoff float64) Quality {
+	for _, quality := range qualityBitrates {
+		if cutoff >= quality.Cutoff {
+			return Quality{Cutoff: cutoff, Bitrate: quality.Bitrate}
+		}
+	}
+	return Quality{Cutoff: cutoff}
+}
+
+// ParseQuality : parse Quality from its string representation
+func ParseQuality(sequence string) (Quality, error) {
+	var quality Quality
+	if _, err := fmt.Sscanf(sequence, "%dk cutoff=%f", &quality.Bitrate, &quality.Cutoff); err != nil {
+		return Quality{}, fmt.Errorf(fmt.Sprintf("Unable to parse quality \"%s\": %s", sequence, err.Error()))
+	}
+	return quality, nil
+}
+
+// String : return Quality summary, as it gets stored into tags
+func (quality Quality) String() string {
+	return fmt.Sprintf("%dk cutoff=%.0f", quality.Bitrate, quality.Cutoff)
+}
+
+// MeasureLoudness : measure input audio file EBU R128 loudness, via ffmpeg loudnorm analysis
+func MeasureLoudness(path string) (Loudness, error) {
+	var commandOut bytes.Buffer
+	commandCmd := "ffmpeg"
+	commandArgs := []string{"-nostdin", "-hide_banner", "-i", path, "-map", "0:a:0", "-af",
+		fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:print_format=json", LoudnormIntegrated, LoudnormTruePeak, LoudnormRange), "-f", "null", "-"}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stderr = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return Loudness{}, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
+	}
+	return parseLoudness(commandOut.String())
+}
+
+// AlbumLoudness : return the loudness of an album made of tracks of input loudnesses and durations, in seconds
+func AlbumLoudness(loudnesses []Loudness, durations []float64) Loudness {
+	var (
+		album    = Loudness{TruePeak: math.Inf(-1)}
+		energy   float64
+		duration float64
+	)
+	for i, loudness := range loudnesses {
+		weight := 1.0
+		if i < len(durations) && durations[i] > 0 {
+			weight = durations[i]
+		}
+		energy += weight * math.Pow(10, loudness.Integrated/10)
+		duration += weight
+		album.TruePeak = math.Max(album.TruePeak, loudness.TruePeak)
+	}
+	if duration > 0 {
+		album.Integrated = 10 * math.Log10(energy/duration)
+	}
+	return album
+}
+
+// LoudnessFromReplayGain : return the loudness matching input ReplayGain gain and peak values
+func LoudnessFromReplayGain(gain string, peak string) (Loudness, error) {
+	var (
+		gainValue float64
+		peakValue float64
+	)
+	if _, err := fmt.Sscanf(gain, "%f dB", &gainValue); err != nil {
+		return Loudness{}, fmt.Errorf(fmt.Sprintf("Unable to parse ReplayGain gain \"%s\": %s", gain, err.Error()))
+	}
+	if _, err := fmt.Sscanf(peak, "%f", &peakValue); err != nil || peakValue <= 0 {
+		return Loudness{}, fmt.Errorf(fmt.Sprintf("Unable to parse ReplayGain peak \"%s\"", peak))
+	}
+	return Loudness{Integrated: ReplayGainReference - gainValue, TruePeak: 20 * math.Log10(peakValue)}, nil
+}
+
+// Gain : return ReplayGain gain value matching Loudness
+func (loudness Loudness) Gain() string {
+	return fmt.Sprintf("%+.2f dB", ReplayGainReference-loudness.Integrated)
+}
+
+// Peak : return ReplayGain peak value matching Loudness
+func (loudness Loudness) Peak() string {
+	return fmt.Sprintf("%.6f", math.Pow(10, loudness.TruePeak/20))
+}
+
+// Filter : return loudnorm second pass filter, fed with Loudness measurement
+func (loudness Loudness) Filter() string {
+	return fmt.Sprintf("loudnorm=I=%.1f:TP=%.1f:LRA=%.1f:measured_I=%.2f:measured_TP=%.2f:measured_LRA=%.2f:measured_thresh=%.2f:offset=%.2f:linear=true",
+		LoudnormIntegrated, LoudnormTruePeak, LoudnormRange, loudness.Integrated, loudness.TruePeak, loudness.Range, loudness.Threshold, loudness.Offset)
+}
@@ -0,0 +1,179 @@
+package audio
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestAlign(t *testing.T) {
+	var (
+		random  = rand.New(rand.NewSource(1))
+		samples = make([]float64, 60*AlignmentSampleRate)
+		offset  = 17 * AlignmentSampleRate
+	)
+	for i := range samples {
+		samples[i] = random.Float64()*2 - 1
+	}
+	preview := make([]float64, 5*AlignmentSampleRate)
+	for i := range preview {
+		preview[i] = samples[offset+i]*0.8 + (random.Float64()*2-1)*0.1
+	}
+
+	alignment := Align(samples, preview)
+	if math.Abs(alignment.Offset-17) > 1.0/AlignmentSampleRate || !alignment.Trusted() {
+		t.Errorf("preview aligned at %s, expected at 17s and trusted", alignment.String())
+	}
+
+	for i := range preview {
+		preview[i] = random.Float64()*2 - 1
+	}
+	if alignment := Align(samples, preview); alignment.Trusted() {
+		t.Errorf("unrelated preview expected not to be trusted: %s", alignment.String())
+	}
+}
+
+func TestAlignmentWindow(t *testing.T) {
+	for _, fixture := range []struct {
+		alignment Alignment
+		duration  float64
+		expected  float64
+		from      float64
+		to        float64
+	}{
+		{Alignment{Offset: 210}, 260, 200, 40, 260},
+		{Alignment{Offset: 30}, 260, 200, 0, 230},
+		{Alignment{Offset: 200}, 260, 230, 0, 260},
+		{Alignment{Offset: 200}, 230, 200, 30, 230},
+	} {
+		from, to := fixture.alignment.Window(fixture.duration, 30, fixture.expected)
+		if from != fixture.from || to != fixture.to {
+			t.Errorf("window for %s = [%.0f, %.0f], expected [%.0f, %.0f]", fixture.alignment.String(), from, to, fixture.from, fixture.to)
+		}
+	}
+}
+
+func TestFingerprintSimilarity(t *testing.T) {
+	var (
+		random    = rand.New(rand.NewSource(1))
+		full      = make(Fingerprint, 1600)
+		unrelated = make(Fingerprint, 240)
+	)
+	for i := range full {
+		full[i] = random.Uint32()
+	}
+	for i := range unrelated {
+		unrelated[i] = random.Uint32()
+	}
+	preview := append(Fingerprint{}, full[700:940]...)
+	for i := range preview {
+		preview[i] ^= 1 << uint(random.Intn(32))
+	}
+
+	if similarity := preview.Similarity(full); similarity < FingerprintAccept {
+		t.Errorf("preview similarity = %.2f, expected to be accepted", similarity)
+	}
+	if similarity := full.Similarity(preview); similarity < FingerprintAccept {
+		t.Errorf("reversed preview similarity = %.2f, expected to be accepted", similarity)
+	}
+	if similarity := unrelated.Similarity(full); similarity >= FingerprintReject {
+		t.Errorf("unrelated similarity = %.2f, expected to be rejected", similarity)
+	}
+}
+
+func TestCutoff(t *testing.T) {
+	var (
+		random  = rand.New(rand.NewSource(1))
+		samples = make([]complex128, 1<<18)
+	)
+	for i := range samples {
+		samples[i] = complex(random.Float64()*2-1, 0)
+	}
+	for _, cutoff := range []float64{16000, 19800} {
+		filtered := append([]complex128{}, samples...)
+		fft(filtered, false)
+		for i := range filtered {
+			frequency := float64(i) * QualitySampleRate / float64(len(filtered))
+			if frequency > cutoff && frequency < QualitySampleRate-cutoff {
+				filtered[i] = 0
+			}
+		}
+		fft(filtered, true)
+		filteredSamples := make([]float64, len(filtered))
+		for i := range filtered {
+			filteredSamples[i] = real(filtered[i])
+		}
+		if estimated := Cutoff(filteredSamples, QualitySampleRate); math.Abs(estimated-cutoff) > 2*QualityBandWidth {
+			t.Errorf("estimated cutoff = %.0f, expected about %.0f", estimated, cutoff)
+		}
+	}
+
+	plainSamples := make([]float64, len(samples))
+	for i := range samples {
+		plainSamples[i] = real(samples[i])
+	}
+	if estimated := Cutoff(plainSamples, QualitySampleRate); estimated != QualitySampleRate/2 {
+		t.Errorf("estimated cutoff for full band audio = %.0f, expected %d", estimated, QualitySampleRate/2)
+	}
+}
+
+func TestQuality(t *testing.T) {
+	for _, fixture := range []struct {
+		cutoff  float64
+		bitrate int
+	}{
+		{22050, 320},
+		{19750, 320},
+		{16000, 128},
+		{11000, 64},
+	} {
+		quality := QualityFromCutoff(fixture.cutoff)
+		if quality.Bitrate != fixture.bitrate {
+			t.Errorf("quality for %.0f Hz cutoff = %dk, expected %dk", fixture.cutoff, quality.Bitrate, fixture.bitrate)
+		}
+		if parsed, err := ParseQuality(quality.String()); err != nil || parsed != quality {
+			t.Errorf("parsed quality %q = %v, expected %v", quality.String(), parsed, quality)
+		}
+	}
+}
+
+func TestReplayGain(t *testing.T) {
+	loudness := Loudness{Integrated: -9.83, TruePeak: -0.5}
+	if loudness.Gain() != "-8.17 dB" || loudness.Peak() != "0.944061" {
+		t.Errorf("ReplayGain values = %s, %s", loudness.Gain(), loudness.Peak())
+	}
+	parsed, err := LoudnessFromReplayGain(loudness.Gain(), loudness.Peak())
+	if err != nil || math.Abs(parsed.Integrated-loudness.Integrated) > 0.01 || math.Abs(parsed.TruePeak-loudness.TruePeak) > 0.01 {
+		t.Errorf("loudness from ReplayGain values = %+v, expected %+v", parsed, loudness)
+	}
+
+	album := AlbumLoudness([]Loudness{{Integrated: -10, TruePeak: -1}, {Integrated: -10, TruePeak: -0.2}}, []float64{200, 100})
+	if math.Abs(album.Integrated+10) > 0.001 || album.TruePeak != -0.2 {
+		t.Errorf("album loudness = %+v", album)
+	}
+	album = AlbumLoudness([]Loudness{{Integrated: -10}, {Integrated: -20}}, []float64{100, 100})
+	if math.Abs(album.Integrated-(-12.596)) > 0.01 {
+		t.Errorf("album loudness = %+v, expected energy weighted average", album)
+	}
+}
+
+func TestSilenceWindow(t *testing.T) {
+	silence := Silence{Leading: 4, Trailing: 7, Duration: 210}
+	for _, fixture := range []struct {
+		from, to, expected float64
+		expectedFrom       float64
+		expectedTo         float64
+	}{
+		{0, 0, 190, 4, 203},
+		{0, 0, 200, 4, 204},
+		{0, 0, 210, 0, 210},
+		{0, 0, 215, 0, 210},
+		{5, 205, 195, 5, 203},
+	} {
+		from, to := silence.Window(fixture.from, fixture.to, fixture.expected)
+		if math.Abs(from-fixture.expectedFrom) > 0.001 || math.Abs(to-fixture.expectedTo) > 0.001 {
+			t.Errorf("Window(%.0f, %.0f, %.0f) = [%.1f, %.1f], expected [%.1f, %.1f]",
+				fixture.from, fixture.to, fixture.expected, from, to, fixture.expectedFrom, fixture.expectedTo)
+		}
+	}
+}
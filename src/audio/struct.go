@@ -0,0 +1,53 @@
+package audio
+
+// Probe : struct containing all the informations gathered while decoding an audio file
+type Probe struct {
+	Duration  float64
+	Errors    []string
+	MaxVolume float64
+}
+
+// Alignment : struct containing all the informations about a preview position inside an audio file
+type Alignment struct {
+	Offset     float64
+	Confidence float64
+}
+
+// Fingerprint : Chromaprint raw audio fingerprint
+type Fingerprint []uint32
+
+// Quality : struct containing all the informations about an audio estimated quality
+type Quality struct {
+	Cutoff  float64
+	Bitrate int
+}
+
+// Encoding : struct containing all the informations about how audio gets encoded
+type Encoding struct {
+	Codec      string
+	Bitrate    string
+	VBR        int
+	SampleRate int
+}
+
+// Loudness : struct containing all the informations about an audio EBU R128 loudness
+type Loudness struct {
+	Integrated float64
+	TruePeak   float64
+	Range      float64
+	Threshold  float64
+	Offset     float64
+}
+
+// Silence : struct containing leading and trailing silence lengths of an audio, in seconds
+type Silence struct {
+	Leading  float64
+	Trailing float64
+	Duration float64
+}
+
+// Format : struct containing an output format extension and its default encoding
+type Format struct {
+	Extension string
+	Encoding  Encoding
+}
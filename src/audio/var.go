@@ -0,0 +1,30 @@
+package audio
+
+import (
+	"regexp"
+
+	spttb_system "system"
+)
+
+var (
+	probeTimePattern      = regexp.MustCompile(`time=(\d+):(\d+):(\d+(?:\.\d+)?)`)
+	probeMaxVolumePattern = regexp.MustCompile(`max_volume:\s*(\S+)\s*dB`)
+	silenceStartPattern   = regexp.MustCompile(`silence_start:\s*(-?\d+(?:\.\d+)?)`)
+	silenceEndPattern     = regexp.MustCompile(`silence_end:\s*(-?\d+(?:\.\d+)?)`)
+	qualityBitrates       = []Quality{
+		{Cutoff: 19500, Bitrate: 320},
+		{Cutoff: 18500, Bitrate: 256},
+		{Cutoff: 17500, Bitrate: 192},
+		{Cutoff: 16000, Bitrate: 128},
+		{Cutoff: 14500, Bitrate: 96},
+		{Cutoff: 0, Bitrate: 64},
+	}
+	// Formats : map binding every output format name to its extension and default encoding
+	Formats = map[string]Format{
+		FormatMP3:  {Extension: spttb_system.SongExtension, Encoding: Encoding{Codec: EncodingCodec, Bitrate: EncodingBitrate, VBR: -1}},
+		FormatFLAC: {Extension: spttb_system.SongExtensionFLAC, Encoding: Encoding{Codec: "flac", VBR: -1}},
+		FormatOpus: {Extension: spttb_system.SongExtensionOpus, Encoding: Encoding{Codec: "libopus", Bitrate: "192k", VBR: -1}},
+		FormatM4A:  {Extension: spttb_system.SongExtensionM4A, Encoding: Encoding{Codec: "aac", Bitrate: "256k", VBR: -1}},
+		FormatOgg:  {Extension: spttb_system.SongExtensionOgg, Encoding: Encoding{Codec: "libvorbis", VBR: 6}},
+	}
+)
@@ -1,13 +1,13 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"os"
 	"os/exec"
@@ -21,6 +21,7 @@ import (
 	"syscall"
 	"time"
 
+	spttb_audio "audio"
 	spttb_gui "gui"
 	spttb_logger "logger"
 	spttb_spotify "spotify"
@@ -29,7 +30,7 @@ import (
 	spttb_youtube "youtube"
 
 	"github.com/0xAX/notificator"
-	id3 "github.com/bogem/id3v2"
+	"github.com/bradfitz/slice"
 	"github.com/kennygrant/sanitize"
 	api "github.com/zmb3/spotify"
 )
@@ -43,6 +44,7 @@ var (
 	argFlushMissing          *bool
 	argFlushDifferent        *bool
 	argDisableNormalization  *bool
+	argNormalization         *string
 	argDisablePlaylistFile   *bool
 	argPlsFile               *bool
 	argDisableLyrics         *bool
@@ -50,20 +52,68 @@ var (
 	argDisableUpdateCheck    *bool
 	argDisableBrowserOpening *bool
 	argDisableIndexing       *bool
+	argDisableVerification   *bool
+	argAlignPreview          *bool
+	argTrimSilence           *bool
+	argSilenceThreshold      *float64
+	argSilenceDuration       *float64
+	argFingerprint           *bool
+	argQualityThreshold      *int
+	argFormat                *string
+	argEncodingCodec         *string
+	argEncodingBitrate       *string
+	argEncodingVBR           *int
+	argEncodingSampleRate    *int
+	argQualityReport         *bool
+	argMigrateTags           *bool
+	argAudit                 *bool
+	argArtworkSize           *int
+	argFolderCover           *bool
+	argImport                *bool
+	argImportConfidence      *float64
+	argAuditReport           *string
+	argAuditFix              *string
+	argSyncedLyrics          *bool
+	argLyricsSidecar         *bool
+	argImportLRC             *bool
+	argLyricsProviders       *string
+	argLyricsTransliterate   *bool
+	argPathTemplate          *string
+	argLibraryPathTemplate   *string
+	argPlaylistPathTemplate  *string
 	argInteractive           *bool
 	argManualInput           *bool
 	argRemoveDuplicates      *bool
 	argCleanJunks            *bool
+	argWorkspace             *string
+	argDiscardWorkspaces     *bool
 	argLog                   *bool
 	argDisableGui            *bool
 	argDebug                 *bool
 	argSimulate              *bool
 	argVersion               *bool
-	argFix                   spttb_system.PathsArrayFlag
+	argMappingsImport        *string
+	argMappingsExport        *string
+	argFiltersList           *bool
+	argFix                   spttb_system.StringsArrayFlag
+	argFixFile               *string
+	argBlacklist             spttb_system.StringsArrayFlag
+	argUnblacklist           spttb_system.StringsArrayFlag
+	argChannelAllow          spttb_system.StringsArrayFlag
+	argChannelDeny           spttb_system.StringsArrayFlag
+	argChannelForget         spttb_system.StringsArrayFlag
 
 	tracks        spttb_track.Tracks
 	tracksFailed  spttb_track.Tracks
-	tracksIndex   = spttb_track.TracksIndex{}
+	tracksFlagged spttb_track.Tracks
+	tracksIndex   = spttb_track.NewIndex()
+	tracksFixes   = make(map[string]spttb_track.Fix)
+	workspace     *spttb_system.Workspace
+	artworkCache  *spttb_track.ArtworkCache
+	fixes         []spttb_track.Fix
+	tracksMapping = spttb_youtube.Mappings{}
+	lyricsCache   = spttb_track.LyricsCache{}
+	channels      = spttb_youtube.Channels{}
 	playlistInfo  *api.FullPlaylist
 	spotifyClient *spttb_spotify.Spotify = spttb_spotify.NewClient()
 	spotifyUser   string
@@ -75,11 +125,16 @@ var (
 	gui    *spttb_gui.Gui
 	notify *notificator.Notificator
 
-	procCurrentBin      string
-	userLocalConfigPath string = spttb_system.LocalConfigPath()
-	userLocalBin               = fmt.Sprintf("%s/spotitube", userLocalConfigPath)
-	userLocalIndex             = fmt.Sprintf("%s/index.gob", userLocalConfigPath)
-	userLocalGob               = fmt.Sprintf("%s/%s_%s.gob", userLocalConfigPath, "%s", "%s")
+	procCurrentBin        string
+	userLocalConfigPath   string = spttb_system.LocalConfigPath()
+	userLocalBin                 = fmt.Sprintf("%s/spotitube", userLocalConfigPath)
+	userLocalIndex               = fmt.Sprintf("%s/index.gob", userLocalConfigPath)
+	userLocalMappings            = fmt.Sprintf("%s/mappings.gob", userLocalConfigPath)
+	userLocalChannels            = fmt.Sprintf("%s/channels.gob", userLocalConfigPath)
+	userLocalLyrics              = fmt.Sprintf("%s/lyrics.gob", userLocalConfigPath)
+	userLocalArtworks            = fmt.Sprintf("%s/artworks", userLocalConfigPath)
+	userLocalVersionRules        = fmt.Sprintf("%s/versions.json", userLocalConfigPath)
+	userLocalGob                 = fmt.Sprintf("%s/%s_%s.gob", userLocalConfigPath, "%s", "%s")
 )
 
 func main() {
@@ -100,35 +155,76 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(spttb_track.GeniusAccessToken) != 64 && len(os.Getenv("GENIUS_TOKEN")) != 64 {
+	if len(spttb_track.GeniusAccessToken) != spttb_track.GeniusAccessTokenLength && len(os.Getenv("GENIUS_TOKEN")) != spttb_track.GeniusAccessTokenLength {
 		fmt.Println(fmt.Sprintf("WARNING: Unknown GENIUS_TOKEN: please, export SPOTIFY_KEY enviroment variable, if you wan't to fetch lyrics from Genius provider."))
 	}
 
 	argFolder = flag.String("folder", ".", "Folder to sync with music")
 	argPlaylist = flag.String("playlist", "none", "Playlist URI to synchronize")
 	argInvalidateCache = flag.Bool("invalidate-cache", false, "Manually invalidate library cache, retriggering its fetch from Spotify")
-	flag.Var(&argFix, "fix", "Offline song filename(s) which straighten the shot to")
+	flag.Var(&argFix, "fix", "Song(s) to straighten the shot to, as <path-or-spotify-uri>[,<video-url>][,redownload|metadata]")
+	argFixFile = flag.String("fix-file", "", "Text or CSV file of songs to fix, one -fix per line")
 	argReplaceLocal = flag.Bool("replace-local", false, "Replace local library songs if better results get encountered")
 	argFlushMetadata = flag.Bool("flush-metadata", false, "Flush metadata informations to already synchronized songs")
 	argFlushMissing = flag.Bool("flush-missing", false, "If -flush-metadata toggled, it will just populate empty id3 frames, instead of flushing any of those")
 	argFlushDifferent = flag.Bool("flush-different", false, "If -flush-metadata toggled, it will just populate id3 frames different from the ones calculated by the application, instead of flushing any of those")
 	argDisableNormalization = flag.Bool("disable-normalization", false, "Disable songs volume normalization")
+	argNormalization = flag.String("normalization", spttb_audio.NormalizationPeak, "Songs volume normalization mode: peak, replaygain (tags only) or loudnorm (two-pass EBU R128)")
 	argDisablePlaylistFile = flag.Bool("disable-playlist-file", false, "Disable automatic creation of playlists file")
 	argPlsFile = flag.Bool("pls-file", false, "Generate playlist file with .pls instead of .m3u")
 	argDisableLyrics = flag.Bool("disable-lyrics", false, "Disable download of songs lyrics and their application into mp3")
+	argLyricsProviders = flag.String("lyrics-providers", spttb_track.DefaultLyricsProviders.String(), "Comma separated lyrics providers to be consulted, in order: genius, ovh, lrclib")
+	argLyricsTransliterate = flag.Bool("lyrics-transliterate", false, "Also store a Latin transliteration of songs lyrics, if written in another script")
+	argSyncedLyrics = flag.Bool("synced-lyrics", false, "Also fetch time-synced lyrics and store them into SYLT frames")
+	argLyricsSidecar = flag.Bool("lyrics-sidecar", false, "Along with -synced-lyrics, also save synced lyrics into .lrc files next to songs")
+	argImportLRC = flag.Bool("import-lrc", false, "Attach existing .lrc files to songs matching them by filename or Spotify ID (along with -simulate, just report them)")
+	argArtworkSize = flag.Int("artwork-size", 0, "Resize artworks to fit into this size (pixels), keeping their aspect ratio (default keeps the largest one available)")
+	argFolderCover = flag.Bool("folder-cover", false, "Also write album artwork into songs folders, as cover.jpg and folder.jpg")
 	argDisableTimestampFlush = flag.Bool("disable-timestamp-flush", false, "Disable automatic songs files timestamps flush")
 	argDisableUpdateCheck = flag.Bool("disable-update-check", false, "Disable automatic update check at startup")
 	argDisableBrowserOpening = flag.Bool("disable-browser-opening", false, "Disable automatic browser opening for authentication")
 	argDisableIndexing = flag.Bool("disable-indexing", false, "Disable automatic library indexing (used to keep track of tracks names modifications)")
+	argDisableVerification = flag.Bool("disable-verification", false, "Disable decoded duration and integrity verification of downloaded songs")
+	argPathTemplate = flag.String("path-template", spttb_track.PathTemplateDefault, "Songs path template, relative to -folder, made of "+strings.Join(spttb_track.PathTemplateFields, ", ")+" fields (e.g. {albumartist}/{year} - {album}/{track:2} {title})")
+	argLibraryPathTemplate = flag.String("library-path-template", "", "Songs path template overriding -path-template while synchronizing library")
+	argPlaylistPathTemplate = flag.String("playlist-path-template", "", "Songs path template overriding -path-template while synchronizing playlists")
+	argFormat = flag.String("format", spttb_audio.FormatMP3, "Format songs get encoded into: mp3, flac, opus, m4a or ogg")
+	argEncodingCodec = flag.String("codec", "", "Codec songs get encoded with (default depends on -format)")
+	argEncodingBitrate = flag.String("bitrate", "", "Bitrate songs get encoded at (default depends on -format)")
+	argEncodingVBR = flag.Int("vbr", -1, "Variable bitrate quality songs get encoded at, overriding -bitrate (codec specific, e.g. 0-9 for libmp3lame)")
+	argEncodingSampleRate = flag.Int("sample-rate", 0, "Sample rate songs get encoded at (default keeps the original one)")
+	argQualityThreshold = flag.Int("quality-threshold", 0, "Along with -replace-local, only replace songs whose estimated quality (kbps) is lower than this")
+	argQualityReport = flag.Bool("quality-report", false, "Estimate songs real quality and print the worst ones")
+	argMigrateTags = flag.Bool("migrate-tags", false, "Rewrite legacy comment-based tags of local songs into standard frames (along with -simulate, just report them)")
+	argAudit = flag.Bool("audit", false, "Read-only audit of local songs, playlists and junk files, printing a summary and writing a JSON report")
+	argAuditReport = flag.String("audit-report", fmt.Sprintf("%s/audit.json", userLocalConfigPath), "Along with -audit, path the JSON report gets written to (\"-\" to print it)")
+	argAuditFix = flag.String("audit-fix", "", "Along with -audit, comma separated categories to automatically fix: "+strings.Join(spttb_track.AuditFixableCategories, ", ")+" or all (along with -simulate, just report them)")
+	argImport = flag.Bool("import", false, "Match songs without Spotify ID against Spotify, by their tags and filename, then rename and retag them (along with -simulate, just report matches)")
+	argImportConfidence = flag.Float64("import-confidence", spttb_track.ImportConfidenceThreshold, "Along with -import, minimum match confidence (0-1) songs get imported with, without asking for confirmation")
+	argFingerprint = flag.Bool("fingerprint", false, "Compare downloaded songs Chromaprint fingerprint against Spotify preview one to accept, reject or flag them")
+	argAlignPreview = flag.Bool("align-preview", false, "Locate Spotify preview inside downloaded songs to confirm them and trim exceeding intros and outros")
+	argTrimSilence = flag.Bool("trim-silence", false, "Trim leading and trailing silence of downloaded songs, never cutting more than their exceeding length compared to Spotify")
+	argSilenceThreshold = flag.Float64("silence-threshold", spttb_audio.SilenceThreshold, "Along with -trim-silence, volume (dB) below which audio is considered silent")
+	argSilenceDuration = flag.Float64("silence-duration", spttb_audio.SilenceMinDuration, "Along with -trim-silence, minimum length (seconds) of silence to be trimmed")
 	argInteractive = flag.Bool("interactive", false, "Enable interactive mode")
 	argManualInput = flag.Bool("manual-input", false, "Always manually insert YouTube URL used for songs download")
 	argRemoveDuplicates = flag.Bool("remove-duplicates", false, "Remove encountered duplicates from online library/playlist")
-	argCleanJunks = flag.Bool("clean-junks", false, "Scan for junks file and clean them")
+	argCleanJunks = flag.Bool("clean-junks", false, "Remove stale workspaces left behind by interrupted runs")
+	argWorkspace = flag.String("workspace", "", "Folder per-run workspaces, holding intermediate files, get created into (default \".spotitube\" into music folder)")
+	argDiscardWorkspaces = flag.Bool("discard-workspaces", false, "Discard stale workspaces left behind by interrupted runs, instead of resuming their downloads")
 	argLog = flag.Bool("log", false, "Enable logging into file ./spotitube.log")
 	argDisableGui = flag.Bool("disable-gui", false, "Disable GUI to reduce noise and increase readability of program flow")
 	argDebug = flag.Bool("debug", false, "Enable debug messages")
 	argSimulate = flag.Bool("simulate", false, "Simulate process flow, without really altering filesystem")
 	argVersion = flag.Bool("version", false, "Print version")
+	argMappingsImport = flag.String("mappings-import", "", "Import Spotify ID to YouTube video mappings from JSON or CSV file")
+	argMappingsExport = flag.String("mappings-export", "", "Export Spotify ID to YouTube video mappings to JSON or CSV file")
+	flag.Var(&argBlacklist, "blacklist", "Blacklist YouTube video(s) for a Spotify track, as <spotify-id>:<video-id-or-url>")
+	flag.Var(&argUnblacklist, "unblacklist", "Remove YouTube video(s) from a Spotify track blacklist, as <spotify-id>:<video-id-or-url>")
+	flag.Var(&argChannelAllow, "channel-allow", "YouTube channel name(s) whose uploads get boosted while searching")
+	flag.Var(&argChannelDeny, "channel-deny", "YouTube channel name(s) whose uploads get excluded while searching")
+	flag.Var(&argChannelForget, "channel-forget", "YouTube channel name(s) to drop from allowed and denied ones")
+	argFiltersList = flag.Bool("filters-list", false, "Print blacklisted videos and allowed/denied channels")
 	flag.Parse()
 
 	if *argVersion {
@@ -136,8 +232,40 @@ func main() {
 		os.Exit(0)
 	}
 
-	if len(argFix.Paths) > 0 {
-		*argReplaceLocal = true
+	if subIfManage() {
+		mainManage()
+	}
+
+	for _, fixEntry := range argFix.Values {
+		fix, fixErr := spttb_track.ParseFix(strings.Split(fixEntry, ","))
+		if fixErr != nil {
+			fmt.Println(fixErr.Error())
+			os.Exit(1)
+		}
+		fixes = append(fixes, fix)
+	}
+	if len(*argFixFile) > 0 {
+		fileFixes, fixErr := spttb_track.ParseFixFile(*argFixFile)
+		if fixErr != nil {
+			fmt.Println(fixErr.Error())
+			os.Exit(1)
+		}
+		fixes = append(fixes, fileFixes...)
+	}
+	for _, fix := range fixes {
+		if len(fix.URL) == 0 {
+			continue
+		}
+		if urlErr := spttb_youtube.ValidateURL(fix.URL); urlErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to fix \"%s\": %s", fix.Target, urlErr.Error()))
+			os.Exit(1)
+		}
+	}
+	if len(fixes) > 0 {
+		*argFlushMetadata = true
+	}
+
+	if *argImport {
 		*argFlushMetadata = true
 	}
 
@@ -145,6 +273,51 @@ func main() {
 		*argInteractive = true
 	}
 
+	artworkCache = spttb_track.NewArtworkCache(userLocalArtworks, *argArtworkSize)
+
+	if *argNormalization != spttb_audio.NormalizationPeak &&
+		*argNormalization != spttb_audio.NormalizationReplayGain &&
+		*argNormalization != spttb_audio.NormalizationLoudnorm {
+		fmt.Println(fmt.Sprintf("Unknown normalization mode: %s", *argNormalization))
+		os.Exit(1)
+	}
+
+	if providers, err := spttb_track.ParseLyricsProviders(strings.Split(*argLyricsProviders, ",")); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	} else {
+		spttb_track.ActiveLyricsProviders = providers
+	}
+
+	if _, err := spttb_track.ParseAuditFixes(strings.Split(*argAuditFix, ",")); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	if *argAuditReport != "-" {
+		*argAuditReport, _ = filepath.Abs(*argAuditReport)
+	}
+	if len(*argWorkspace) > 0 {
+		*argWorkspace, _ = filepath.Abs(*argWorkspace)
+	}
+
+	if *argPlaylist == "none" && len(*argLibraryPathTemplate) > 0 {
+		*argPathTemplate = *argLibraryPathTemplate
+	} else if *argPlaylist != "none" && len(*argPlaylistPathTemplate) > 0 {
+		*argPathTemplate = *argPlaylistPathTemplate
+	}
+	if err := spttb_track.ValidatePathTemplate(*argPathTemplate); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	spttb_track.ActivePathTemplate = *argPathTemplate
+
+	if format, ok := spttb_audio.Formats[*argFormat]; !ok {
+		fmt.Println(fmt.Sprintf("Unknown output format: %s", *argFormat))
+		os.Exit(1)
+	} else {
+		spttb_track.ActiveExtension = format.Extension
+	}
+
 	if !(spttb_system.Dir(*argFolder)) {
 		fmt.Println(fmt.Sprintf("Chosen music folder does not exist: %s", *argFolder))
 		os.Exit(1)
@@ -156,12 +329,46 @@ func main() {
 		}
 	}
 
+	if len(*argWorkspace) == 0 {
+		*argWorkspace, _ = filepath.Abs(spttb_system.WorkspaceFolder)
+	}
+
 	if *argCleanJunks {
 		junks := subCleanJunks()
-		fmt.Println(fmt.Sprintf("Removed %d junk files.", junks))
+		fmt.Println(fmt.Sprintf("Removed %d stale workspaces.", junks))
 		os.Exit(0)
 	}
 
+	if *argQualityReport {
+		subQualityReport()
+		mainExit()
+	}
+
+	if *argMigrateTags {
+		subMigrateTags()
+		mainExit()
+	}
+
+	if *argImportLRC {
+		subImportLRC()
+		mainExit()
+	}
+
+	if *argAudit {
+		subAudit()
+		mainExit()
+	}
+
+	if !*argSimulate {
+		if workspaceObj, err := spttb_system.NewWorkspace(*argWorkspace); err != nil {
+			fmt.Println(fmt.Sprintf("Unable to create workspace into %s: %s", *argWorkspace, err.Error()))
+			os.Exit(1)
+		} else {
+			workspace = workspaceObj
+			spttb_track.ActiveWorkspace = workspace.Path
+		}
+	}
+
 	spttb_system.Mkdir(userLocalConfigPath)
 
 	var guiOptions uint64
@@ -192,6 +399,11 @@ func main() {
 	subCheckInternet()
 	subCheckUpdate()
 	subFetchIndex()
+	subFetchMappings()
+	subFetchLyricsCache()
+	subFetchChannels()
+	subFetchVersionRules()
+	subRecoverWorkspaces()
 
 	if !*argDisableIndexing {
 		go subAlignIndex()
@@ -215,19 +427,107 @@ func main() {
 	mainFetch()
 }
 
-func mainFetch() {
-	if len(argFix.Paths) == 0 {
-		spotifyAuthURL := spttb_spotify.BuildAuthURL()
-		gui.Append(fmt.Sprintf("Authentication URL: %s", spotifyAuthURL.Short), spttb_gui.PanelRight|spttb_gui.ParagraphStyleAutoReturn)
-		if !*argDisableBrowserOpening {
-			gui.DebugAppend("Waiting for automatic login process. If wait is too long, manually open that URL.", spttb_gui.PanelRight)
+func mainManage() {
+	spttb_system.Mkdir(userLocalConfigPath)
+	if spttb_system.FileExists(userLocalMappings) {
+		spttb_system.FetchGob(userLocalMappings, &tracksMapping)
+	}
+	if spttb_system.FileExists(userLocalChannels) {
+		spttb_system.FetchGob(userLocalChannels, &channels)
+	}
+
+	if len(*argMappingsImport) > 0 {
+		imported, importErr := tracksMapping.Import(*argMappingsImport)
+		if importErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to import mappings: %s", importErr.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(fmt.Sprintf("Imported %d mappings from %s.", imported, *argMappingsImport))
+	}
+	for _, blacklistEntry := range argBlacklist.Values {
+		spotifyID, videoID, entryErr := subParseBlacklistEntry(blacklistEntry)
+		if entryErr != nil {
+			fmt.Println(entryErr.Error())
+			os.Exit(1)
+		}
+		tracksMapping.Reject(spotifyID, videoID, subMappingAuthor(false))
+		fmt.Println(fmt.Sprintf("Video %s blacklisted for track %s.", videoID, spotifyID))
+	}
+	for _, blacklistEntry := range argUnblacklist.Values {
+		spotifyID, videoID, entryErr := subParseBlacklistEntry(blacklistEntry)
+		if entryErr != nil {
+			fmt.Println(entryErr.Error())
+			os.Exit(1)
+		}
+		tracksMapping.Unreject(spotifyID, videoID)
+		fmt.Println(fmt.Sprintf("Video %s removed from track %s blacklist.", videoID, spotifyID))
+	}
+	for _, channel := range argChannelAllow.Values {
+		channels.Allow(channel)
+		fmt.Println(fmt.Sprintf("Channel \"%s\" allowed.", channel))
+	}
+	for _, channel := range argChannelDeny.Values {
+		channels.Deny(channel)
+		fmt.Println(fmt.Sprintf("Channel \"%s\" denied.", channel))
+	}
+	for _, channel := range argChannelForget.Values {
+		channels.Forget(channel)
+		fmt.Println(fmt.Sprintf("Channel \"%s\" forgotten.", channel))
+	}
+
+	if dumpErr := spttb_system.DumpGob(userLocalMappings, tracksMapping); dumpErr != nil {
+		fmt.Println(fmt.Sprintf("Unable to write mappings: %s", dumpErr.Error()))
+		os.Exit(1)
+	}
+	if dumpErr := spttb_system.DumpGob(userLocalChannels, channels); dumpErr != nil {
+		fmt.Println(fmt.Sprintf("Unable to write channels: %s", dumpErr.Error()))
+		os.Exit(1)
+	}
+
+	if len(*argMappingsExport) > 0 {
+		if exportErr := tracksMapping.Export(*argMappingsExport); exportErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to export mappings: %s", exportErr.Error()))
+			os.Exit(1)
+		}
+		fmt.Println(fmt.Sprintf("Exported %d mappings to %s.", len(tracksMapping), *argMappingsExport))
+	}
+
+	if *argFiltersList {
+		var channelNames, spotifyIDs []string
+		for channel := range channels {
+			channelNames = append(channelNames, channel)
+		}
+		for spotifyID := range tracksMapping {
+			spotifyIDs = append(spotifyIDs, spotifyID)
+		}
+		slice.Sort(channelNames, func(i, j int) bool {
+			return channelNames[i] < channelNames[j]
+		})
+		slice.Sort(spotifyIDs, func(i, j int) bool {
+			return spotifyIDs[i] < spotifyIDs[j]
+		})
+		for _, channel := range channelNames {
+			if channels[channel] {
+				fmt.Println(fmt.Sprintf("Allowed channel: %s", channel))
+			} else {
+				fmt.Println(fmt.Sprintf("Denied channel: %s", channel))
+			}
 		}
-		if !spotifyClient.Auth(spotifyAuthURL.Full, !*argDisableBrowserOpening) {
-			gui.Prompt("Unable to authenticate to spotify.", spttb_gui.PromptDismissableWithExit)
+		for _, spotifyID := range spotifyIDs {
+			if mapping := tracksMapping[spotifyID]; len(mapping.Rejected) > 0 {
+				fmt.Println(fmt.Sprintf("Blacklisted for %s: %s", spotifyID, strings.Join(mapping.Rejected, ", ")))
+			}
 		}
-		gui.Append("Authentication completed.", spttb_gui.PanelRight)
-		spotifyUser, spotifyUserID = spotifyClient.User()
-		gui.Append(fmt.Sprintf("%s %s", spttb_gui.MessageStyle("Session user:", spttb_gui.FontStyleBold), spotifyUser), spttb_gui.PanelLeftTop)
+	}
+	os.Exit(0)
+}
+
+func mainFetch() {
+	if *argImport {
+		subSpotifyAuth()
+		subImportTracks()
+	} else if len(fixes) == 0 {
+		subSpotifyAuth()
 
 		var (
 			tracksOnline          []api.FullTrack
@@ -340,16 +640,7 @@ func mainFetch() {
 		<-waitIndex
 		close(waitIndex)
 	} else {
-		gui.Append(fmt.Sprintf("%s %d", spttb_gui.MessageStyle("Fix song(s):", spttb_gui.FontStyleBold), len(argFix.Paths)), spttb_gui.PanelLeftTop)
-		for _, fixTrack := range argFix.Paths {
-			if track, trackErr := spttb_track.OpenLocalTrack(fixTrack); trackErr != nil {
-				gui.Prompt(fmt.Sprintf("Something went wrong: %s.", trackErr.Error()), spttb_gui.PromptDismissableWithExit)
-				mainExit()
-			} else {
-				gui.DebugAppend(fmt.Sprintf("%+v\n", track), spttb_gui.PanelRight)
-				tracks = append(tracks, track)
-			}
-		}
+		subFixTracks()
 	}
 
 	for range [spttb_system.ConcurrencyLimit]int{} {
@@ -364,9 +655,197 @@ func mainFetch() {
 	}
 }
 
+func subSpotifyAuth() {
+	spotifyAuthURL := spttb_spotify.BuildAuthURL()
+	gui.Append(fmt.Sprintf("Authentication URL: %s", spotifyAuthURL.Short), spttb_gui.PanelRight|spttb_gui.ParagraphStyleAutoReturn)
+	if !*argDisableBrowserOpening {
+		gui.DebugAppend("Waiting for automatic login process. If wait is too long, manually open that URL.", spttb_gui.PanelRight)
+	}
+	if !spotifyClient.Auth(spotifyAuthURL.Full, !*argDisableBrowserOpening) {
+		gui.Prompt("Unable to authenticate to spotify.", spttb_gui.PromptDismissableWithExit)
+	}
+	gui.Append("Authentication completed.", spttb_gui.PanelRight)
+	spotifyUser, spotifyUserID = spotifyClient.User()
+	gui.Append(fmt.Sprintf("%s %s", spttb_gui.MessageStyle("Session user:", spttb_gui.FontStyleBold), spotifyUser), spttb_gui.PanelLeftTop)
+}
+
+func subFixTracks() {
+	gui.Append(fmt.Sprintf("%s %d", spttb_gui.MessageStyle("Fix song(s):", spttb_gui.FontStyleBold), len(fixes)), spttb_gui.PanelLeftTop)
+	for _, fix := range fixes {
+		if len(fix.SpotifyID) > 0 || fix.Mode == spttb_track.FixModeMetadata {
+			subSpotifyAuth()
+			break
+		}
+	}
+	if !*argDisableIndexing {
+		<-waitIndex
+		close(waitIndex)
+	}
+
+	for _, fix := range fixes {
+		var (
+			track    spttb_track.Track
+			trackErr error
+		)
+		if len(fix.Path) > 0 {
+			if track, trackErr = spttb_track.OpenLocalTrack(fix.Path); trackErr != nil {
+				gui.Prompt(fmt.Sprintf("Something went wrong: %s.", trackErr.Error()), spttb_gui.PromptDismissableWithExit)
+				mainExit()
+			} else if len(track.SpotifyID) == 0 {
+				gui.Prompt(fmt.Sprintf("Song \"%s\" has no Spotify ID: import it first, through -import.", fix.Path), spttb_gui.PromptDismissableWithExit)
+				mainExit()
+			}
+			fix.SpotifyID = track.SpotifyID
+		}
+		if _, ok := tracksFixes[fix.SpotifyID]; ok {
+			gui.WarnAppend(fmt.Sprintf("Ignored fix duplicate %s.", fix.Target), spttb_gui.PanelRight)
+			continue
+		}
+
+		if len(fix.Path) > 0 {
+			fix.Snapshot(fix.Path)
+			if fix.Mode == spttb_track.FixModeRedownload {
+				gui.DebugAppend(fmt.Sprintf("%+v\n", track), spttb_gui.PanelRight)
+				tracks = append(tracks, track)
+				tracksFixes[fix.SpotifyID] = fix
+				continue
+			}
+		} else if trackPath, ok := tracksIndex.Path(fix.SpotifyID); ok {
+			fix.Snapshot(trackPath)
+		}
+
+		spotifyTrack, spotifyTrackErr := spotifyClient.Track(fix.SpotifyID)
+		if spotifyTrackErr != nil {
+			gui.Prompt(spotifyTrackErr.Error(), spttb_gui.PromptDismissableWithExit)
+			mainExit()
+		}
+		spotifyAlbums, spotifyAlbumsErr := spotifyClient.Albums([]api.ID{spotifyTrack.Album.ID})
+		if spotifyAlbumsErr != nil || len(spotifyAlbums) == 0 {
+			gui.Prompt(fmt.Sprintf("Something went wrong while fetching album info for %s.", fix.Target), spttb_gui.PromptDismissableWithExit)
+			mainExit()
+		}
+		track = spttb_track.ParseSpotifyTrack(spotifyTrack, spotifyAlbums[0])
+		if len(fix.Path) > 0 {
+			trackPath := fix.Path
+			if workingDir, err := os.Getwd(); err == nil {
+				if trackPathRel, err := filepath.Rel(workingDir, fix.Path); err == nil {
+					trackPath = trackPathRel
+				}
+			}
+			track.FilenameExt = filepath.Ext(trackPath)
+			track.URL = fix.Frames[spttb_track.ID3FrameYouTubeURL]
+			track.Local = true
+			tracksIndex.Relocate(track.SpotifyID, trackPath)
+		}
+		gui.DebugAppend(fmt.Sprintf("%+v\n", track), spttb_gui.PanelRight)
+		tracks = append(tracks, track)
+		tracksFixes[fix.SpotifyID] = fix
+	}
+}
+
+func subFixSummary() {
+	for _, track := range tracks {
+		fix, ok := tracksFixes[track.SpotifyID]
+		if !ok {
+			continue
+		}
+		var failed bool
+		for _, trackFailed := range tracksFailed {
+			failed = failed || trackFailed.SpotifyID == track.SpotifyID
+		}
+		if failed {
+			gui.Append(fmt.Sprintf(" - %s: failed", fix.Target), spttb_gui.PanelRight)
+		} else if !spttb_system.FileExists(track.FilenameFinal()) {
+			gui.Append(fmt.Sprintf(" - %s: not synchronized", fix.Target), spttb_gui.PanelRight)
+		} else if changes := fix.Changes(track.FilenameFinal()); len(changes) == 0 {
+			gui.Append(fmt.Sprintf(" - %s: nothing changed", fix.Target), spttb_gui.PanelRight)
+		} else {
+			gui.Append(fmt.Sprintf(" - %s: %s", fix.Target, strings.Join(changes, ", ")), spttb_gui.PanelRight)
+		}
+	}
+}
+
+func subImportTracks() {
+	if !*argDisableIndexing {
+		<-waitIndex
+		close(waitIndex)
+	}
+
+	var (
+		importPaths     []string
+		importMatches   []api.FullTrack
+		importAlbumsIds []api.ID
+		importIDs       = make(map[string]bool)
+	)
+	gui.Append("Matching songs without Spotify ID against Spotify...", spttb_gui.PanelRight)
+	for _, path := range subLibraryPaths(spttb_system.SongExtensions...) {
+		if len(spttb_track.GetTag(path, spttb_track.ID3FrameSpotifyID)) > 0 {
+			continue
+		}
+
+		track := spttb_track.OpenUntaggedTrack(path)
+		if duration, durationErr := spttb_audio.Duration(path); durationErr == nil {
+			track.Duration = int(duration)
+		}
+		var spotifyTracks []api.FullTrack
+		for _, query := range track.ImportQueries() {
+			gui.DebugAppend(fmt.Sprintf("Searching Spotify for \"%s\" with query: %s", path, query), spttb_gui.PanelRight)
+			var spotifyTracksErr error
+			if spotifyTracks, spotifyTracksErr = spotifyClient.SearchTracks(query); spotifyTracksErr != nil {
+				gui.WarnAppend(spotifyTracksErr.Error(), spttb_gui.PanelRight)
+			} else if len(spotifyTracks) > 0 {
+				break
+			}
+		}
+
+		match, confidence := track.ImportMatch(spotifyTracks)
+		if confidence < spttb_track.ImportConfidenceMinimum || len(match.SimpleTrack.Artists) == 0 {
+			gui.WarnAppend(fmt.Sprintf("No Spotify match found for \"%s\".", path), spttb_gui.PanelRight)
+			continue
+		}
+		matchDescription := fmt.Sprintf("\"%s\" by \"%s\" (%s, %d:%02d)", match.SimpleTrack.Name, match.SimpleTrack.Artists[0].Name,
+			match.Album.Name, match.SimpleTrack.Duration/60000, match.SimpleTrack.Duration/1000%60)
+		if confidence < *argImportConfidence &&
+			!gui.PromptInput(fmt.Sprintf("Do you want to import \"%s\" as %s?\nConfidence: %.0f%%", path, matchDescription, confidence*100), spttb_gui.OptionNil) {
+			gui.Append(fmt.Sprintf("Song \"%s\" skipped.", path), spttb_gui.PanelRight)
+			continue
+		}
+		if trackPath, ok := tracksIndex.Path(match.SimpleTrack.ID.String()); ok || importIDs[match.SimpleTrack.ID.String()] {
+			gui.WarnAppend(fmt.Sprintf("Song \"%s\" matches %s, which has already been synchronized or imported: %s.", path, matchDescription, trackPath), spttb_gui.PanelRight)
+			continue
+		}
+		gui.Append(fmt.Sprintf("Song \"%s\" matches %s, with %.0f%% confidence.", path, matchDescription, confidence*100), spttb_gui.PanelRight)
+		importPaths = append(importPaths, path)
+		importMatches = append(importMatches, match)
+		importAlbumsIds = append(importAlbumsIds, match.Album.ID)
+		importIDs[match.SimpleTrack.ID.String()] = true
+	}
+	if len(importMatches) == 0 {
+		return
+	}
+
+	importAlbums, importAlbumsErr := spotifyClient.Albums(importAlbumsIds)
+	if importAlbumsErr != nil {
+		gui.Prompt(fmt.Sprintf("Something went wrong while fetching album info: %s.", importAlbumsErr.Error()), spttb_gui.PromptDismissableWithExit)
+	}
+	var imported int
+	for importIndex, match := range importMatches {
+		track := spttb_track.ParseSpotifyTrack(match, importAlbums[importIndex])
+		track.FilenameExt = filepath.Ext(importPaths[importIndex])
+		track.Local = true
+		if *argSimulate {
+			gui.Append(fmt.Sprintf("I would import \"%s\" as \"%s\", but I'm just simulating.", importPaths[importIndex], track.FilenameFinal()), spttb_gui.PanelRight)
+			continue
+		}
+		tracksIndex.Relocate(track.SpotifyID, importPaths[importIndex])
+		tracks = append(tracks, track)
+		imported++
+	}
+	gui.Append(fmt.Sprintf("%s %d", spttb_gui.MessageStyle("Songs imported:", spttb_gui.FontStyleBold), imported), spttb_gui.PanelLeftTop)
+}
+
 func mainSearch() {
 	defer mainExit()
-	defer subCleanJunks()
 
 	gui.LoadingSetMax(len(tracks))
 
@@ -377,18 +856,26 @@ func mainSearch() {
 		gui.LoadingHalfIncrease()
 		gui.Append(fmt.Sprintf("%d/%d: \"%s\"", trackIndex+1, len(tracks), track.Filename), spttb_gui.PanelRight|spttb_gui.FontStyleBold)
 
-		if trackPath, ok := tracksIndex[track.SpotifyID]; ok {
+		if trackPath, ok := tracksIndex.Path(track.SpotifyID); ok {
 			if trackPath != track.FilenameFinal() {
 				gui.Append(fmt.Sprintf("Track %s has been renamed: moving local one to %s", track.SpotifyID, track.FilenameFinal()), spttb_gui.PanelRight)
+				spttb_system.Mkdir(filepath.Dir(track.FilenameFinal()))
 				if err := os.Rename(trackPath, track.FilenameFinal()); err != nil {
 					gui.ErrAppend(fmt.Sprintf("Unable to move song: %s", err.Error()), spttb_gui.PanelRight)
 				} else {
 					track.Local = true
-					tracksIndex[track.SpotifyID] = track.FilenameFinal()
+					tracksIndex.Relocate(track.SpotifyID, track.FilenameFinal())
+					trackLyricsPath := strings.TrimSuffix(trackPath, filepath.Ext(trackPath)) + spttb_track.LyricsSidecarExtension
+					if spttb_system.FileExists(trackLyricsPath) {
+						os.Rename(trackLyricsPath, track.FilenameLyrics())
+					}
+					spttb_system.DirPrune(trackPath)
 				}
 			}
 		}
 
+		subCondQualityEstimate(&track)
+
 		if subIfSongSearch(track) {
 			var (
 				youTubeTrack         = spttb_youtube.Track{Track: &track}
@@ -397,8 +884,12 @@ func mainSearch() {
 				youTubeTrackPickAuto bool
 				youTubeTrackPick     bool
 			)
-			if !*argManualInput {
-				youTubeTracks, youTubeTracksErr = spttb_youtube.QueryTracks(&track)
+			if fix, ok := tracksFixes[track.SpotifyID]; ok && len(fix.URL) > 0 {
+				gui.Append(fmt.Sprintf("Video %s has been chosen for \"%s\".", fix.URL, track.Filename), spttb_gui.PanelRight)
+				youTubeTrack = spttb_youtube.Track{Track: &track, ID: spttb_youtube.IDFromURL(fix.URL), URL: fix.URL, Title: "input video", Strategy: spttb_youtube.QueryStrategyManual}
+				youTubeTrackPick = true
+			} else if !*argManualInput {
+				youTubeTracks, youTubeTracksErr = spttb_youtube.QueryTracks(&track, subQueryMappings(track), channels)
 				if youTubeTracksErr != nil {
 					gui.WarnAppend(fmt.Sprintf("Something went wrong while searching for \"%s\" track: %s.", track.Filename, youTubeTracksErr.Error()), spttb_gui.PanelRight)
 					tracksFailed = append(tracksFailed, track)
@@ -406,14 +897,16 @@ func mainSearch() {
 					continue
 				}
 				for _, youTubeTrackLoopEl := range youTubeTracks {
-					gui.DebugAppend(fmt.Sprintf("Result met: ID: %s,\nTitle: %s,\nUser: %s,\nDuration: %d.",
-						youTubeTrackLoopEl.ID, youTubeTrackLoopEl.Title, youTubeTrackLoopEl.User, youTubeTrackLoopEl.Duration), spttb_gui.PanelRight)
+					gui.DebugAppend(fmt.Sprintf("Result met: ID: %s,\nTitle: %s,\nUser: %s,\nDuration: %d,\nStrategy: %s.",
+						youTubeTrackLoopEl.ID, youTubeTrackLoopEl.Title, youTubeTrackLoopEl.User, youTubeTrackLoopEl.Duration, youTubeTrackLoopEl.Strategy), spttb_gui.PanelRight)
 
 					youTubeTrackPickAuto, youTubeTrackPick = subMatchResult(track, youTubeTrackLoopEl)
 					if subIfPickFromAns(youTubeTrackPickAuto, youTubeTrackPick) {
-						gui.Append(fmt.Sprintf("Video \"%s\" is good to go for \"%s\".", youTubeTrackLoopEl.Title, track.Filename), spttb_gui.PanelRight)
+						gui.Append(fmt.Sprintf("Video \"%s\" is good to go for \"%s\" (%s query).", youTubeTrackLoopEl.Title, track.Filename, youTubeTrackLoopEl.Strategy), spttb_gui.PanelRight)
 						youTubeTrack = youTubeTrackLoopEl
 						break
+					} else if *argInteractive && !*argSimulate {
+						tracksMapping.Reject(track.SpotifyID, youTubeTrackLoopEl.ID, subMappingAuthor(false))
 					}
 				}
 			}
@@ -436,7 +929,7 @@ func mainSearch() {
 				gui.Append(fmt.Sprintf("I would like to download \"%s\" for \"%s\" track, but I'm just simulating.", youTubeTrack.URL, track.Filename), spttb_gui.PanelRight)
 				gui.LoadingHalfIncrease()
 				continue
-			} else if *argReplaceLocal {
+			} else if subIfSongReplace(track) {
 				if track.URL == youTubeTrack.URL && !youTubeTrackPick {
 					gui.Append(fmt.Sprintf("Track \"%s\" is still the best result I can find.", track.Filename), spttb_gui.PanelRight)
 					gui.DebugAppend(fmt.Sprintf("Local track origin URL %s is the same as YouTube chosen one %s.", track.URL, youTubeTrack.URL), spttb_gui.PanelRight)
@@ -448,15 +941,22 @@ func mainSearch() {
 				}
 			}
 
-			gui.Append(fmt.Sprintf("Going to download \"%s\" from %s...", youTubeTrack.Title, youTubeTrack.URL), spttb_gui.PanelRight)
-			err := youTubeTrack.Download()
+			youTubeTrack, err := subDownloadVerified(&track, youTubeTrack, youTubeTracks)
 			if err != nil {
 				gui.WarnAppend(fmt.Sprintf("Something went wrong downloading \"%s\": %s.", track.Filename, err.Error()), spttb_gui.PanelRight)
 				tracksFailed = append(tracksFailed, track)
 				gui.LoadingHalfIncrease()
 				continue
+			} else if !subSongQuality(&track) {
+				gui.Append(fmt.Sprintf("Track \"%s\" has not been upgraded, as no better quality has been found.", track.Filename), spttb_gui.PanelRight)
+				os.Remove(track.FilenameStream())
+				gui.LoadingHalfIncrease()
+				continue
 			} else {
 				track.URL = youTubeTrack.URL
+				if len(youTubeTrack.ID) > 0 && !youTubeTrack.Mapped {
+					tracksMapping.Pick(track.SpotifyID, youTubeTrack.ID, youTubeTrack.Strategy, subMappingAuthor(!*argInteractive && !youTubeTrackPick))
+				}
 			}
 		}
 
@@ -476,21 +976,32 @@ func mainSearch() {
 	}
 	waitGroup.Wait()
 
+	subCondAlbumGain()
 	subCondPlaylistFileWrite()
 	subCondTimestampFlush()
 	subWriteIndex()
-
-	junks := subCleanJunks()
-	gui.Append(fmt.Sprintf("Removed %d junk files.", junks), spttb_gui.PanelRight)
+	subWriteMappings()
+	subWriteLyricsCache()
 
 	close(waitGroupPool)
 	waitGroup.Wait()
 	gui.LoadingFill()
 
+	if len(tracksFixes) > 0 {
+		gui.Append(fmt.Sprintf("%d fixed songs summary:", len(tracksFixes)), spttb_gui.PanelRight)
+		subFixSummary()
+	}
+
 	gui.Append(fmt.Sprintf("%d tracks failed to synchronize.", len(tracksFailed)), spttb_gui.PanelRight)
 	for _, track := range tracksFailed {
 		gui.Append(fmt.Sprintf(" - \"%s\"", track.Filename), spttb_gui.PanelRight)
 	}
+	if len(tracksFlagged) > 0 {
+		gui.Append(fmt.Sprintf("%d tracks flagged for review, as their fingerprint is just partially matching:", len(tracksFlagged)), spttb_gui.PanelRight)
+		for _, track := range tracksFlagged {
+			gui.Append(fmt.Sprintf(" - \"%s\"", track.Filename), spttb_gui.PanelRight)
+		}
+	}
 
 	var (
 		notify = notificator.New(notificator.Options{
@@ -520,11 +1031,21 @@ func mainExit(delay ...time.Duration) {
 		time.Sleep(delay[0])
 	}
 
+	if workspace != nil {
+		workspace.Close()
+	}
 	os.Exit(0)
 }
 
 func subCheckDependencies() {
-	for _, commandName := range []string{"youtube-dl", "ffmpeg"} {
+	commandNames := []string{"youtube-dl", "ffmpeg"}
+	if *argFormat != spttb_audio.FormatMP3 || *argImport {
+		commandNames = append(commandNames, "ffprobe")
+	}
+	if *argFingerprint {
+		commandNames = append(commandNames, "fpcalc")
+	}
+	for _, commandName := range commandNames {
 		_, err := exec.LookPath(commandName)
 		if err != nil {
 			gui.Prompt(fmt.Sprintf("Are you sure %s is asctually installed?", commandName), spttb_gui.PromptDismissableWithExit)
@@ -537,7 +1058,11 @@ func subCheckDependencies() {
 			if versionRegex, versionRegexErr := regexp.Compile(commandVersionRegex); versionRegexErr != nil {
 				commandVersionValue = "Regex compile failure"
 			} else {
-				commandObj := exec.Command(commandName, []string{"--version"}...)
+				commandVersionArg := "--version"
+				if commandName == "fpcalc" {
+					commandVersionArg = "-version"
+				}
+				commandObj := exec.Command(commandName, []string{commandVersionArg}...)
 				commandObj.Stdout = &commandOut
 				commandObj.Stderr = &commandOut
 				_ = commandObj.Run()
@@ -622,35 +1147,157 @@ func subFetchIndex() {
 		return
 	}
 	gui.DebugAppend("Fetching local index...", spttb_gui.PanelRight)
-	spttb_system.FetchGob(userLocalIndex, tracksIndex)
+	if index, fetchErr := spttb_track.LoadIndex(userLocalIndex); fetchErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to load tracks index, it will be rebuilt: %s", fetchErr.Error()), spttb_gui.PanelRight)
+	} else {
+		tracksIndex = index
+	}
 }
 
 func subWriteIndex() {
-	gui.DebugAppend(fmt.Sprintf("Writing %d entries index...", len(tracksIndex)), spttb_gui.PanelRight)
-	if writeErr := spttb_system.DumpGob(userLocalIndex, tracksIndex); writeErr != nil {
+	gui.DebugAppend(fmt.Sprintf("Writing %d entries index...", tracksIndex.Len()), spttb_gui.PanelRight)
+	if writeErr := tracksIndex.Dump(userLocalIndex); writeErr != nil {
 		gui.WarnAppend(fmt.Sprintf("Unable to write tracks index: %s", writeErr.Error()), spttb_gui.PanelRight)
 	}
 }
 
-func subAlignIndex() {
-	gui.Append("Indexing started...", spttb_gui.PanelRight)
+func subFetchMappings() {
+	if !spttb_system.FileExists(userLocalMappings) {
+		gui.DebugAppend("No tracks mapping has been found.", spttb_gui.PanelRight)
+		return
+	}
+	gui.DebugAppend("Fetching tracks mapping...", spttb_gui.PanelRight)
+	if fetchErr := spttb_system.FetchGob(userLocalMappings, &tracksMapping); fetchErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to load tracks mapping: %s", fetchErr.Error()), spttb_gui.PanelRight)
+	}
+}
+
+func subWriteMappings() {
+	if *argSimulate {
+		return
+	}
+	gui.DebugAppend(fmt.Sprintf("Writing %d entries tracks mapping...", len(tracksMapping)), spttb_gui.PanelRight)
+	if writeErr := spttb_system.DumpGob(userLocalMappings, tracksMapping); writeErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to write tracks mapping: %s", writeErr.Error()), spttb_gui.PanelRight)
+	}
+}
+
+func subFetchLyricsCache() {
+	if !spttb_system.FileExists(userLocalLyrics) {
+		return
+	}
+	gui.DebugAppend("Fetching lyrics cache...", spttb_gui.PanelRight)
+	if fetchErr := spttb_system.FetchGob(userLocalLyrics, &lyricsCache); fetchErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to load lyrics cache: %s", fetchErr.Error()), spttb_gui.PanelRight)
+	}
+}
+
+func subWriteLyricsCache() {
+	if *argSimulate || *argDisableLyrics {
+		return
+	}
+	gui.DebugAppend(fmt.Sprintf("Writing %d entries lyrics cache...", len(lyricsCache)), spttb_gui.PanelRight)
+	if writeErr := spttb_system.DumpGob(userLocalLyrics, lyricsCache); writeErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to write lyrics cache: %s", writeErr.Error()), spttb_gui.PanelRight)
+	}
+}
+
+func subFetchChannels() {
+	if !spttb_system.FileExists(userLocalChannels) {
+		return
+	}
+	gui.DebugAppend("Fetching channels preferences...", spttb_gui.PanelRight)
+	if fetchErr := spttb_system.FetchGob(userLocalChannels, &channels); fetchErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to load channels preferences: %s", fetchErr.Error()), spttb_gui.PanelRight)
+	}
+}
+
+func subFetchVersionRules() {
+	if !spttb_system.FileExists(userLocalVersionRules) {
+		gui.DebugAppend(fmt.Sprintf("Writing default version rules to %s...", userLocalVersionRules), spttb_gui.PanelRight)
+		if dumpErr := spttb_track.DefaultVersionRules.Dump(userLocalVersionRules); dumpErr != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to write default version rules: %s", dumpErr.Error()), spttb_gui.PanelRight)
+		}
+		return
+	}
+	gui.DebugAppend("Fetching version rules...", spttb_gui.PanelRight)
+	rules, rulesErr := spttb_track.LoadVersionRules(userLocalVersionRules)
+	if rulesErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to load version rules, falling back to default ones: %s", rulesErr.Error()), spttb_gui.PanelRight)
+		return
+	}
+	spttb_track.ActiveVersionRules = rules
+}
+
+func subIfManage() bool {
+	return len(*argMappingsImport) > 0 || len(*argMappingsExport) > 0 || *argFiltersList ||
+		len(argBlacklist.Values) > 0 || len(argUnblacklist.Values) > 0 ||
+		len(argChannelAllow.Values) > 0 || len(argChannelDeny.Values) > 0 || len(argChannelForget.Values) > 0
+}
+
+func subParseBlacklistEntry(entry string) (string, string, error) {
+	entry = strings.TrimPrefix(strings.TrimSpace(entry), "spotify:track:")
+	if !strings.Contains(entry, ":") {
+		return "", "", fmt.Errorf("Malformed blacklist entry \"%s\": expected <spotify-id>:<video-id-or-url>", entry)
+	}
+	var (
+		spotifyID = strings.SplitN(entry, ":", 2)[0]
+		videoID   = strings.SplitN(entry, ":", 2)[1]
+	)
+	if spttb_youtube.ValidateURL(videoID) == nil {
+		videoID = spttb_youtube.IDFromURL(videoID)
+	}
+	return spotifyID, videoID, nil
+}
+
+func subQueryMappings(track spttb_track.Track) spttb_youtube.Mappings {
+	if subIfSongReplace(track) {
+		return tracksMapping.Curated()
+	}
+	return tracksMapping
+}
+
+func subMappingAuthor(automated bool) string {
+	if automated {
+		return spttb_youtube.MappingAuthorAuto
+	}
+	if user, err := user.Current(); err == nil {
+		return user.Username
+	}
+	return "unknown"
+}
+
+func subLibraryPaths(extensions ...string) []string {
+	var paths []string
 	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-		if info == nil || info.IsDir() || filepath.Ext(path) != ".mp3" || strings.Contains(path, "/") {
+		if info == nil {
+			return nil
+		} else if info.IsDir() {
+			if path != "." && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		} else if info.Mode()&os.ModeSymlink != 0 || strings.HasPrefix(info.Name(), ".") {
 			return nil
 		}
-
-		gui.DebugAppend(fmt.Sprintf("Index: path %s", path), spttb_gui.PanelRight)
-		spotifyID := spttb_track.GetTag(path, spttb_track.ID3FrameSpotifyID)
-		if len(spotifyID) > 0 {
-			tracksIndex[spotifyID] = path
-		} else {
-			gui.DebugAppend(fmt.Sprintf("Index: no ID found. Ignoring %s...", path), spttb_gui.PanelRight)
+		for _, extension := range extensions {
+			if strings.ToLower(filepath.Ext(path)) == extension {
+				paths = append(paths, path)
+				break
+			}
 		}
-
 		return nil
 	})
+	return paths
+}
+
+func subAlignIndex() {
+	gui.Append("Indexing started...", spttb_gui.PanelRight)
+	paths := subLibraryPaths(spttb_system.SongExtensions...)
+	scanned := tracksIndex.Scan(paths, spttb_track.IndexScanWorkers)
+	gui.DebugAppend(fmt.Sprintf("Index: %d out of %d songs were new or modified, and got read.", scanned, len(paths)), spttb_gui.PanelRight)
 	waitIndex <- true
-	gui.Append(fmt.Sprintf("Indexing finished: %d tracks indexed.", len(tracksIndex)), spttb_gui.PanelRight)
+	gui.Append(fmt.Sprintf("Indexing finished: %d tracks indexed.", tracksIndex.Len()), spttb_gui.PanelRight)
 }
 
 func subUpdateSoftware(latestRelease []byte) {
@@ -733,9 +1380,16 @@ func subParallelSongProcess(track spttb_track.Track, wg *sync.WaitGroup) {
 	defer gui.LoadingHalfIncrease()
 	defer wg.Done()
 	<-waitGroupPool
+	defer func() {
+		waitGroupPool <- true
+	}()
 
-	if !track.Local && !*argDisableNormalization {
-		subSongNormalize(track)
+	if !track.Local {
+		if err := subSongEncode(track); err != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to encode song \"%s\": %s", track.Filename, err.Error()), spttb_gui.PanelRight)
+			return
+		}
+		subCondSongLoudness(&track)
 	}
 
 	if !spttb_system.FileExists(track.FilenameTemporary()) && spttb_system.FileExists(track.FilenameFinal()) {
@@ -749,279 +1403,938 @@ func subParallelSongProcess(track spttb_track.Track, wg *sync.WaitGroup) {
 		subSongFlushMetadata(track)
 	}
 
-	os.Remove(track.FilenameFinal())
-	err := os.Rename(track.FilenameTemporary(), track.FilenameFinal())
+	spttb_system.Mkdir(filepath.Dir(track.FilenameFinal()))
+	err := spttb_system.FileMove(track.FilenameTemporary(), track.FilenameFinal())
 	if err != nil {
 		gui.WarnAppend(fmt.Sprintf("Unable to move song to its final path: %s", err.Error()), spttb_gui.PanelRight)
+	} else {
+		if len(track.Fingerprint) > 0 {
+			tracksIndex.Fingerprint(track.SpotifyID, track.FilenameFinal(), track.URL, track.Fingerprint)
+		}
+		subCondLyricsSidecar(track)
+		subCondFolderCover(track)
 	}
-
-	waitGroupPool <- true
 }
 
-func subSongNormalize(track spttb_track.Track) {
-	var (
-		commandCmd         = "ffmpeg"
-		commandArgs        []string
-		commandOut         bytes.Buffer
-		commandErr         error
-		normalizationDelta string
-		normalizationFile  = strings.Replace(track.FilenameTemporary(), track.FilenameExt, ".norm"+track.FilenameExt, -1)
-	)
-
-	commandArgs = []string{"-i", track.FilenameTemporary(), "-af", "volumedetect", "-f", "null", "-y", "null"}
-	gui.DebugAppend(fmt.Sprintf("Getting max_volume value: \"%s %s\"...", commandCmd, strings.Join(commandArgs, " ")), spttb_gui.PanelRight)
-	commandObj := exec.Command(commandCmd, commandArgs...)
-	commandObj.Stderr = &commandOut
-	commandErr = commandObj.Run()
-	if commandErr != nil {
-		gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to pull max_volume song value: %s.", commandOut.String()), spttb_gui.PanelRight)
-		normalizationDelta = "0.0"
-	} else {
-		commandScanner := bufio.NewScanner(strings.NewReader(commandOut.String()))
-		for commandScanner.Scan() {
-			if strings.Contains(commandScanner.Text(), "max_volume:") {
-				normalizationDelta = strings.Split(strings.Split(commandScanner.Text(), "max_volume:")[1], " ")[1]
-				normalizationDelta = strings.Replace(normalizationDelta, "-", "", -1)
-			}
+func subDownloadVerified(track *spttb_track.Track, youTubeTrack spttb_youtube.Track, youTubeTracks spttb_youtube.Tracks) (spttb_youtube.Track, error) {
+	for youTubeTrackIndex, youTubeTrackLoopEl := range youTubeTracks {
+		if youTubeTrackLoopEl.ID == youTubeTrack.ID {
+			youTubeTracks = youTubeTracks[youTubeTrackIndex+1:]
+			break
 		}
 	}
 
-	if _, commandErr = strconv.ParseFloat(normalizationDelta, 64); commandErr != nil {
-		gui.WarnAppend(fmt.Sprintf("Unable to pull max_volume delta to be applied along with song volume normalization: %s.", normalizationDelta), spttb_gui.PanelRight)
-		normalizationDelta = "0.0"
+	for {
+		gui.Append(fmt.Sprintf("Going to download \"%s\" from %s...", youTubeTrack.Title, youTubeTrack.URL), spttb_gui.PanelRight)
+		if err := youTubeTrack.Download(); err != nil {
+			return youTubeTrack, err
+		}
+
+		track.Verification = ""
+		track.Fingerprint = nil
+		track.TrimFrom, track.TrimTo = 0, 0
+		verifyBlame, verifyErr := subSongAlign(track)
+		if verifyErr == nil {
+			verifyBlame, verifyErr = subSongSilence(track)
+		}
+		if verifyErr == nil {
+			verifyBlame, verifyErr = subSongVerify(track)
+		}
+		if verifyErr == nil {
+			verifyBlame, verifyErr = subSongFingerprint(track)
+		}
+		if verifyErr == nil {
+			return youTubeTrack, nil
+		}
+		gui.WarnAppend(fmt.Sprintf("Video \"%s\" downloaded for \"%s\" did not pass verification: %s.", youTubeTrack.Title, track.Filename, verifyErr.Error()), spttb_gui.PanelRight)
+		os.Remove(track.FilenameStream())
+		if verifyBlame && len(youTubeTrack.ID) > 0 && !youTubeTrack.Mapped && youTubeTrack.Strategy != spttb_youtube.QueryStrategyManual {
+			tracksMapping.Reject(track.SpotifyID, youTubeTrack.ID, subMappingAuthor(true))
+		}
+
+		youTubeTrack = spttb_youtube.Track{}
+		for len(youTubeTracks) > 0 && youTubeTrack.URL == "" {
+			youTubeTrackLoopEl := youTubeTracks[0]
+			youTubeTracks = youTubeTracks[1:]
+			if youTubeTrackPickAuto, youTubeTrackPick := subMatchResult(*track, youTubeTrackLoopEl); subIfPickFromAns(youTubeTrackPickAuto, youTubeTrackPick) {
+				gui.Append(fmt.Sprintf("Falling back to video \"%s\" for \"%s\" (%s query).", youTubeTrackLoopEl.Title, track.Filename, youTubeTrackLoopEl.Strategy), spttb_gui.PanelRight)
+				youTubeTrack = youTubeTrackLoopEl
+				youTubeTrack.Track = track
+			} else if *argInteractive {
+				tracksMapping.Reject(track.SpotifyID, youTubeTrackLoopEl.ID, subMappingAuthor(false))
+			}
+		}
+		if youTubeTrack.URL == "" {
+			return youTubeTrack, fmt.Errorf("no video passed verification, last one failing due to: %s", verifyErr.Error())
+		}
+	}
+}
+
+func subSongAlign(track *spttb_track.Track) (bool, error) {
+	if !*argAlignPreview || len(track.Preview) == 0 {
+		return false, nil
+	}
+	gui.DebugAppend(fmt.Sprintf("Aligning \"%s\" to its Spotify preview...", track.Filename), spttb_gui.PanelRight)
+	preview, previewErr := spttb_audio.Decode(track.Preview)
+	if previewErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to fetch \"%s\" Spotify preview: %s", track.Filename, previewErr.Error()), spttb_gui.PanelRight)
+		return false, nil
+	}
+	samples, samplesErr := spttb_audio.Decode(track.FilenameStream())
+	if samplesErr != nil {
+		return false, samplesErr
+	}
+
+	alignment := spttb_audio.Align(samples, preview)
+	gui.DebugAppend(fmt.Sprintf("Alignment outcome for \"%s\": %s.", track.Filename, alignment.String()), spttb_gui.PanelRight)
+	track.Verification = alignment.String()
+	if !alignment.Trusted() {
+		return true, fmt.Errorf(fmt.Sprintf("Spotify preview cannot be found inside downloaded audio (confidence: %.2f, min: %.2f)",
+			alignment.Confidence, spttb_audio.AlignmentConfidence))
+	}
+
+	var (
+		duration        = float64(len(samples)) / spttb_audio.AlignmentSampleRate
+		previewDuration = float64(len(preview)) / spttb_audio.AlignmentSampleRate
+	)
+	trimFrom, trimTo := alignment.Window(duration, previewDuration, float64(track.Duration))
+	if trimFrom < spttb_audio.AlignmentTrimTolerance && duration-trimTo < spttb_audio.AlignmentTrimTolerance {
+		return false, nil
+	}
+	gui.Append(fmt.Sprintf("\"%s\" will be trimmed to [%.1fs, %.1fs] out of %.1fs.", track.Filename, trimFrom, trimTo, duration), spttb_gui.PanelRight)
+	track.TrimFrom, track.TrimTo = trimFrom, trimTo
+	return false, nil
+}
+
+func subSongSilence(track *spttb_track.Track) (bool, error) {
+	if !*argTrimSilence {
+		return false, nil
+	}
+	gui.DebugAppend(fmt.Sprintf("Detecting \"%s\" leading and trailing silence...", track.Filename), spttb_gui.PanelRight)
+	silence, silenceErr := spttb_audio.DetectSilence(track.FilenameStream(), *argSilenceThreshold, *argSilenceDuration)
+	if silenceErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to detect \"%s\" silence: %s", track.Filename, silenceErr.Error()), spttb_gui.PanelRight)
+		return false, nil
 	}
-	commandArgs = []string{"-i", track.FilenameTemporary(), "-af", "volume=+" + normalizationDelta + "dB", "-b:a", "320k", "-y", normalizationFile}
-	gui.DebugAppend(fmt.Sprintf("Compensating volume by %sdB...", normalizationDelta), spttb_gui.PanelRight)
-	gui.DebugAppend(fmt.Sprintf("Increasing audio quality for: %s...", track.Filename), spttb_gui.PanelRight)
-	gui.DebugAppend(fmt.Sprintf("Firing command: \"%s %s\"...", commandCmd, strings.Join(commandArgs, " ")), spttb_gui.PanelRight)
-	if _, commandErr = exec.Command(commandCmd, commandArgs...).Output(); commandErr != nil {
-		gui.WarnAppend(fmt.Sprintf("Something went wrong while normalizing song \"%s\" volume: %s", track.Filename, commandErr.Error()), spttb_gui.PanelRight)
+
+	windowTo := track.TrimTo
+	if windowTo == 0 {
+		windowTo = silence.Duration
 	}
-	os.Remove(track.FilenameTemporary())
-	os.Rename(normalizationFile, track.FilenameTemporary())
+	trimFrom, trimTo := silence.Window(track.TrimFrom, windowTo, float64(track.Duration))
+	if trimFrom-track.TrimFrom < *argSilenceDuration && windowTo-trimTo < *argSilenceDuration {
+		return false, nil
+	}
+	gui.Append(fmt.Sprintf("\"%s\" silence will be trimmed, keeping [%.1fs, %.1fs] out of %.1fs.", track.Filename, trimFrom, trimTo, silence.Duration), spttb_gui.PanelRight)
+	track.TrimFrom, track.TrimTo = trimFrom, trimTo
+	return false, nil
+}
+
+func subSongFingerprint(track *spttb_track.Track) (bool, error) {
+	if !*argFingerprint {
+		return false, nil
+	}
+	gui.DebugAppend(fmt.Sprintf("Fingerprinting \"%s\"...", track.Filename), spttb_gui.PanelRight)
+	fingerprint, fingerprintErr := spttb_audio.FingerprintOf(track.FilenameStream())
+	if fingerprintErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to fingerprint \"%s\": %s", track.Filename, fingerprintErr.Error()), spttb_gui.PanelRight)
+		return false, nil
+	}
+	if len(track.Preview) > 0 {
+		previewFingerprint, previewFingerprintErr := spttb_audio.FingerprintOf(track.Preview)
+		if previewFingerprintErr != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to fingerprint \"%s\" Spotify preview: %s", track.Filename, previewFingerprintErr.Error()), spttb_gui.PanelRight)
+		} else {
+			similarity := previewFingerprint.Similarity(fingerprint)
+			gui.DebugAppend(fmt.Sprintf("Fingerprint similarity for \"%s\": %.2f.", track.Filename, similarity), spttb_gui.PanelRight)
+			track.Verification = strings.TrimSpace(fmt.Sprintf("%s fingerprint_similarity=%.2f", track.Verification, similarity))
+			if similarity < spttb_audio.FingerprintReject {
+				return true, fmt.Errorf(fmt.Sprintf("Fingerprint is too distant from Spotify preview one (similarity: %.2f, min: %.2f)",
+					similarity, spttb_audio.FingerprintReject))
+			} else if similarity < spttb_audio.FingerprintAccept {
+				gui.WarnAppend(fmt.Sprintf("Fingerprint of \"%s\" is just partially similar to Spotify preview one (%.2f): flagging it for review.",
+					track.Filename, similarity), spttb_gui.PanelRight)
+				track.Verification = track.Verification + " review"
+				tracksFlagged = append(tracksFlagged, *track)
+			}
+		}
+	}
+	track.Fingerprint = fingerprint
+	return false, nil
+}
+
+func subSongQuality(track *spttb_track.Track) bool {
+	qualityLocal, qualityLocalErr := spttb_audio.ParseQuality(track.Quality)
+	quality, qualityErr := spttb_audio.EstimateQuality(track.FilenameStream())
+	if qualityErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to estimate \"%s\" quality: %s", track.Filename, qualityErr.Error()), spttb_gui.PanelRight)
+		track.Quality = ""
+		return true
+	}
+	gui.Append(fmt.Sprintf("Estimated \"%s\" real quality: %s.", track.Filename, quality.String()), spttb_gui.PanelRight)
+	track.Quality = quality.String()
+	if *argQualityThreshold > 0 && qualityLocalErr == nil && spttb_system.FileExists(track.FilenameFinal()) {
+		return quality.Bitrate > qualityLocal.Bitrate
+	}
+	return true
+}
+
+func subQualityReport() {
+	var qualities = make(map[string]spttb_audio.Quality)
+	var paths = subLibraryPaths(spttb_system.SongExtensions...)
+	for _, path := range paths {
+		quality, qualityErr := spttb_audio.ParseQuality(spttb_track.GetTag(path, spttb_track.ID3FrameQuality))
+		if qualityErr != nil {
+			if quality, qualityErr = spttb_audio.EstimateQuality(path); qualityErr != nil {
+				fmt.Println(fmt.Sprintf("Unable to estimate \"%s\" quality: %s", path, qualityErr.Error()))
+				continue
+			}
+		}
+		qualities[path] = quality
+	}
+
+	paths = paths[:0]
+	for path := range qualities {
+		paths = append(paths, path)
+	}
+	slice.Sort(paths, func(i, j int) bool {
+		return qualities[paths[i]].Cutoff < qualities[paths[j]].Cutoff
+	})
+	if len(paths) > spttb_audio.QualityReportSize {
+		paths = paths[:spttb_audio.QualityReportSize]
+	}
+	fmt.Println(fmt.Sprintf("Worst %d out of %d songs, by estimated quality:", len(paths), len(qualities)))
+	for _, path := range paths {
+		fmt.Println(fmt.Sprintf("%5dk %6.0f Hz  %s", qualities[path].Bitrate, qualities[path].Cutoff, path))
+	}
+}
+
+func subMigrateTags() {
+	var (
+		songsMigrated  int
+		framesMigrated int
+	)
+	paths := subLibraryPaths(spttb_system.SongExtension)
+	for _, path := range paths {
+		trackTags, trackTagsErr := spttb_track.LoadTags(path, !*argSimulate)
+		if trackTagsErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to read \"%s\" tags: %s", path, trackTagsErr.Error()))
+			continue
+		}
+		if len(trackTags.Legacy) == 0 {
+			trackTags.Close()
+			continue
+		}
+
+		var frameDescriptions []string
+		for _, frame := range trackTags.Legacy {
+			frameDescriptions = append(frameDescriptions, spttb_track.TagFrameDescription(frame))
+			trackTags.Set(frame, trackTags.Get(frame))
+		}
+		if *argSimulate {
+			fmt.Println(fmt.Sprintf("Would migrate %s: %s", path, strings.Join(frameDescriptions, ", ")))
+		} else if err := trackTags.Save(); err != nil {
+			fmt.Println(fmt.Sprintf("Unable to migrate \"%s\" tags: %s", path, err.Error()))
+			trackTags.Close()
+			continue
+		} else {
+			fmt.Println(fmt.Sprintf("Migrated %s: %s", path, strings.Join(frameDescriptions, ", ")))
+		}
+		trackTags.Close()
+		songsMigrated++
+		framesMigrated += len(frameDescriptions)
+	}
+	if *argSimulate {
+		fmt.Println(fmt.Sprintf("%d out of %d songs would get %d legacy frames migrated.", songsMigrated, len(paths), framesMigrated))
+	} else {
+		fmt.Println(fmt.Sprintf("%d out of %d songs got %d legacy frames migrated.", songsMigrated, len(paths), framesMigrated))
+	}
+}
+
+func subImportLRC() {
+	var (
+		songPaths     []string
+		songsByName   = make(map[string]string)
+		songsByID     = make(map[string]string)
+		lyricsPaths   []string
+		lyricsPathsOk int
+	)
+	songPaths = subLibraryPaths(spttb_system.SongExtensions...)
+	for _, path := range songPaths {
+		songsByName[strings.TrimSuffix(path, filepath.Ext(path))] = path
+		if spotifyID := spttb_track.GetTag(path, spttb_track.ID3FrameSpotifyID); len(spotifyID) > 0 {
+			songsByID[spotifyID] = path
+		}
+	}
+
+	lyricsPaths = subLibraryPaths(spttb_track.LyricsSidecarExtension)
+	for _, lyricsPath := range lyricsPaths {
+		lyricsName := strings.TrimSuffix(lyricsPath, spttb_track.LyricsSidecarExtension)
+		path, ok := songsByName[lyricsName]
+		if !ok {
+			if path, ok = songsByID[filepath.Base(lyricsName)]; !ok {
+				fmt.Println(fmt.Sprintf("No song matches \"%s\"", lyricsPath))
+				continue
+			}
+		}
+
+		lyricsText, lyricsErr := ioutil.ReadFile(lyricsPath)
+		if lyricsErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to read \"%s\": %s", lyricsPath, lyricsErr.Error()))
+			continue
+		}
+		lines, linesErr := spttb_track.ParseLRC(string(lyricsText))
+		if linesErr != nil {
+			fmt.Println(fmt.Sprintf("Unable to parse \"%s\": %s", lyricsPath, linesErr.Error()))
+			continue
+		}
+		duration, _ := strconv.Atoi(spttb_track.GetTag(path, spttb_track.ID3FrameDuration))
+		if err := lines.Validate(duration); err != nil {
+			fmt.Println(fmt.Sprintf("Skipping \"%s\", not fitting %s: %s", lyricsPath, path, err.Error()))
+			continue
+		}
+
+		if *argSimulate {
+			fmt.Println(fmt.Sprintf("Would attach %s to %s (%d lines)", lyricsPath, path, len(lines)))
+		} else {
+			trackTags, trackTagsErr := spttb_track.LoadTags(path, true)
+			if trackTagsErr != nil {
+				fmt.Println(fmt.Sprintf("Unable to read \"%s\" tags: %s", path, trackTagsErr.Error()))
+				continue
+			}
+			trackTags.Set(spttb_track.ID3FrameSyncedLyrics, lines.String())
+			err := trackTags.Save()
+			trackTags.Close()
+			if err != nil {
+				fmt.Println(fmt.Sprintf("Unable to attach \"%s\" to %s: %s", lyricsPath, path, err.Error()))
+				continue
+			}
+			fmt.Println(fmt.Sprintf("Attached %s to %s (%d lines)", lyricsPath, path, len(lines)))
+		}
+		lyricsPathsOk++
+	}
+	if *argSimulate {
+		fmt.Println(fmt.Sprintf("%d out of %d lyrics files would get attached.", lyricsPathsOk, len(lyricsPaths)))
+	} else {
+		fmt.Println(fmt.Sprintf("%d out of %d lyrics files got attached.", lyricsPathsOk, len(lyricsPaths)))
+	}
+}
+
+func subAudit() {
+	var (
+		report        = spttb_track.NewAuditReport(*argFolder)
+		fixes, _      = spttb_track.ParseAuditFixes(strings.Split(*argAuditFix, ","))
+		paths         = subLibraryPaths(spttb_system.SongExtensions...)
+		pathsBySpotID = make(map[string][]string)
+		pathsByISRC   = make(map[string][]string)
+		decode        = true
+	)
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		fmt.Println("Unable to find ffmpeg: songs duration and decoding checks will be skipped.")
+		decode = false
+	}
+	if spttb_system.FileExists(userLocalLyrics) {
+		spttb_system.FetchGob(userLocalLyrics, &lyricsCache)
+	}
+
+	report.Songs = len(paths)
+	for _, path := range paths {
+		track, trackArtwork, trackErr := spttb_track.OpenLocalTrackArtwork(path)
+		if trackErr != nil {
+			report.Append(spttb_track.AuditUndecodable, path, trackErr.Error())
+			continue
+		}
+		if len(track.SpotifyID) == 0 {
+			report.Append(spttb_track.AuditNoSpotifyID, path, "")
+		} else {
+			pathsBySpotID[track.SpotifyID] = append(pathsBySpotID[track.SpotifyID], path)
+		}
+		if len(track.ISRC) > 0 {
+			pathsByISRC[track.ISRC] = append(pathsByISRC[track.ISRC], path)
+		}
+		if !trackArtwork {
+			issue := report.Append(spttb_track.AuditMissingArtwork, path, track.Image)
+			report.Issues[issue].Fixed = subAuditFix(spttb_track.AuditMissingArtwork, path, fixes, func() error {
+				return subAuditFixArtwork(path, track)
+			})
+		}
+		if len(track.Lyrics) == 0 {
+			issue := report.Append(spttb_track.AuditMissingLyrics, path, "")
+			report.Issues[issue].Fixed = subAuditFix(spttb_track.AuditMissingLyrics, path, fixes, func() error {
+				return subAuditFixLyrics(path, track)
+			})
+		}
+		if len(track.Genre) == 0 {
+			report.Append(spttb_track.AuditMissingGenre, path, "")
+		}
+		if decode {
+			probe, probeErr := spttb_audio.Analyze(path)
+			if probeErr != nil {
+				report.Append(spttb_track.AuditUndecodable, path, probeErr.Error())
+			} else if probe.Corrupted() || probe.Duration == 0 {
+				report.Append(spttb_track.AuditUndecodable, path, probe.String())
+			} else if track.Duration > 0 && math.Abs(probe.Duration-float64(track.Duration)) > spttb_audio.DurationTolerance {
+				report.Append(spttb_track.AuditDurationMismatch, path,
+					fmt.Sprintf("decoded %.0f seconds, expected %d", probe.Duration, track.Duration))
+			}
+		}
+	}
+	report.AppendDuplicates(spttb_track.AuditDuplicateSpotifyID, pathsBySpotID)
+	report.AppendDuplicates(spttb_track.AuditDuplicateISRC, pathsByISRC)
+
+	filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
+		if info == nil {
+			return nil
+		} else if info.IsDir() && path != "." && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		} else if info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		if _, err := os.Stat(path); err != nil {
+			target, _ := os.Readlink(path)
+			issue := report.Append(spttb_track.AuditBrokenSymlink, path, target)
+			report.Issues[issue].Fixed = subAuditFix(spttb_track.AuditBrokenSymlink, path, fixes, func() error {
+				return os.Remove(path)
+			})
+		}
+		return nil
+	})
+	for _, path := range subJunkPaths() {
+		issue := report.Append(spttb_track.AuditJunk, path, "")
+		report.Issues[issue].Fixed = subAuditFix(spttb_track.AuditJunk, path, fixes, func() error {
+			return os.RemoveAll(path)
+		})
+	}
+
+	if !*argSimulate && subIfAuditFix(fixes, spttb_track.AuditMissingLyrics) {
+		spttb_system.Mkdir(userLocalConfigPath)
+		spttb_system.DumpGob(userLocalLyrics, lyricsCache)
+	}
+
+	fmt.Println(fmt.Sprintf("Audited %d songs into %s:", report.Songs, *argFolder))
+	for _, category := range spttb_track.AuditCategories {
+		if fixed := report.CountFixed(category); fixed > 0 {
+			fmt.Println(fmt.Sprintf("%6d  %s (%d fixed)", report.Summary[category], category, fixed))
+		} else {
+			fmt.Println(fmt.Sprintf("%6d  %s", report.Summary[category], category))
+		}
+	}
+	if *argAuditReport == "-" {
+		if reportJSON, err := report.JSON(); err != nil {
+			fmt.Println(fmt.Sprintf("Unable to encode audit report: %s", err.Error()))
+		} else {
+			fmt.Println(string(reportJSON))
+		}
+		return
+	}
+	spttb_system.Mkdir(filepath.Dir(*argAuditReport))
+	if err := report.Dump(*argAuditReport); err != nil {
+		fmt.Println(fmt.Sprintf("Unable to write audit report: %s", err.Error()))
+		return
+	}
+	fmt.Println(fmt.Sprintf("Audit report written to %s.", *argAuditReport))
+}
+
+func subAuditFix(category string, path string, fixes []string, fix func() error) bool {
+	if !subIfAuditFix(fixes, category) {
+		return false
+	}
+	if *argSimulate {
+		fmt.Println(fmt.Sprintf("Would fix %s: %s", category, path))
+		return false
+	}
+	if err := fix(); err != nil {
+		fmt.Println(fmt.Sprintf("Unable to fix %s of \"%s\": %s", category, path, err.Error()))
+		return false
+	}
+	fmt.Println(fmt.Sprintf("Fixed %s: %s", category, path))
+	return true
+}
+
+func subIfAuditFix(fixes []string, category string) bool {
+	for _, fix := range fixes {
+		if fix == category {
+			return true
+		}
+	}
+	return false
+}
+
+func subAuditFixArtwork(path string, track spttb_track.Track) error {
+	if len(track.Image) == 0 {
+		return fmt.Errorf("No artwork URL has been stored")
+	}
+	artwork, artworkMime, err := artworkCache.Fetch(track.Image)
+	if err != nil {
+		return err
+	}
+	trackTags, err := spttb_track.LoadTags(path, true)
+	if err != nil {
+		return err
+	}
+	defer trackTags.Close()
+	trackTags.SetArtwork(artworkMime, artwork)
+	return trackTags.Save()
+}
+
+func subAuditFixLyrics(path string, track spttb_track.Track) error {
+	if err := track.SearchLyrics(lyricsCache); err != nil {
+		return err
+	}
+	if len(track.Lyrics) == 0 {
+		return fmt.Errorf("No lyrics have been found")
+	}
+	trackTags, err := spttb_track.LoadTags(path, true)
+	if err != nil {
+		return err
+	}
+	defer trackTags.Close()
+	trackTags.Set(spttb_track.ID3FrameLyrics, track.Lyrics)
+	return trackTags.Save()
+}
+
+func subSongVerify(track *spttb_track.Track) (bool, error) {
+	if *argDisableVerification {
+		return false, nil
+	}
+	gui.DebugAppend(fmt.Sprintf("Verifying \"%s\" decoded duration and integrity...", track.Filename), spttb_gui.PanelRight)
+	probe, probeErr := spttb_audio.Analyze(track.FilenameStream())
+	if probeErr != nil {
+		return false, probeErr
+	}
+	if track.TrimTo > 0 {
+		probe.Duration = math.Min(probe.Duration, track.TrimTo) - track.TrimFrom
+	}
+	gui.DebugAppend(fmt.Sprintf("Verification outcome for \"%s\": %s.", track.Filename, probe.String()), spttb_gui.PanelRight)
+	track.Verification = strings.TrimSpace(probe.String() + " " + track.Verification)
+	return probe.Duration > 0 && !probe.Corrupted(), probe.Verify(track.Duration)
+}
+
+func subSongEncode(track spttb_track.Track) error {
+	var encodingFilters []string
+	if !*argDisableNormalization && *argNormalization == spttb_audio.NormalizationPeak {
+		probe, probeErr := spttb_audio.Analyze(track.FilenameStream())
+		if probeErr != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to pull max_volume song value: %s.", probeErr.Error()), spttb_gui.PanelRight)
+		} else if probe.MaxVolume < 0 && probe.MaxVolume > spttb_audio.SilenceThreshold {
+			gui.DebugAppend(fmt.Sprintf("Compensating volume by %.1fdB...", -probe.MaxVolume), spttb_gui.PanelRight)
+			encodingFilters = append(encodingFilters, fmt.Sprintf("volume=%.1fdB", -probe.MaxVolume))
+		}
+	} else if !*argDisableNormalization && *argNormalization == spttb_audio.NormalizationLoudnorm {
+		loudness, loudnessErr := spttb_audio.MeasureLoudness(track.FilenameStream())
+		if loudnessErr != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to measure song loudness: %s.", loudnessErr.Error()), spttb_gui.PanelRight)
+		} else {
+			gui.DebugAppend(fmt.Sprintf("Normalizing loudness from %.1f LUFS to %.1f LUFS...", loudness.Integrated, spttb_audio.LoudnormIntegrated), spttb_gui.PanelRight)
+			encodingFilters = append(encodingFilters, loudness.Filter())
+			if *argEncodingSampleRate == 0 {
+				encodingFilters = append(encodingFilters, fmt.Sprintf("aresample=%d", spttb_audio.LoudnormSampleRate))
+			}
+		}
+	}
+
+	encoding := spttb_audio.Formats[*argFormat].Encoding
+	if track.FilenameExt != spttb_track.ActiveExtension {
+		// replaced local songs keep their own format
+		for _, format := range spttb_audio.Formats {
+			if format.Extension == track.FilenameExt {
+				encoding = format.Encoding
+			}
+		}
+	} else if len(*argEncodingCodec) > 0 {
+		encoding.Codec = *argEncodingCodec
+	}
+	if len(*argEncodingBitrate) > 0 && track.FilenameExt == spttb_track.ActiveExtension {
+		encoding.Bitrate, encoding.VBR = *argEncodingBitrate, -1
+	}
+	if *argEncodingVBR >= 0 && track.FilenameExt == spttb_track.ActiveExtension {
+		encoding.VBR = *argEncodingVBR
+	}
+	encoding.SampleRate = *argEncodingSampleRate
+	gui.DebugAppend(fmt.Sprintf("Encoding \"%s\" (%s)...", track.Filename, strings.Join(encoding.Args(), " ")), spttb_gui.PanelRight)
+	encodingErr := spttb_audio.Encode(track.FilenameStream(), track.FilenameTemporary(), encoding, encodingFilters, track.TrimFrom, track.TrimTo)
+	os.Remove(track.FilenameStream())
+	return encodingErr
 }
 
 func subSongFlushMetadata(track spttb_track.Track) {
-	trackMp3, err := id3.Open(track.FilenameTemporary(), id3.Options{Parse: true})
+	trackTags, err := spttb_track.LoadTags(track.FilenameTemporary(), true)
 	if err != nil {
 		gui.WarnAppend(fmt.Sprintf("Something bad happened while opening: %s", err.Error()), spttb_gui.PanelRight)
 	} else {
 		gui.DebugAppend(fmt.Sprintf("Fixing metadata for \"%s\"...", track.Filename), spttb_gui.PanelRight)
 		if !*argFlushMissing && !*argFlushDifferent {
-			trackMp3.DeleteAllFrames()
-		}
-		subCondFlushID3FrameTitle(track, trackMp3)
-		subCondFlushID3FrameSong(track, trackMp3)
-		subCondFlushID3FrameArtist(track, trackMp3)
-		subCondFlushID3FrameAlbum(track, trackMp3)
-		subCondFlushID3FrameGenre(track, trackMp3)
-		subCondFlushID3FrameYear(track, trackMp3)
-		subCondFlushID3FrameFeaturings(track, trackMp3)
-		subCondFlushID3FrameTrackNumber(track, trackMp3)
-		subCondFlushID3FrameTrackTotals(track, trackMp3)
-		subCondFlushID3FrameArtwork(track, trackMp3)
-		subCondFlushID3FrameArtworkURL(track, trackMp3)
-		subCondFlushID3FrameYouTubeURL(track, trackMp3)
-		subCondFlushID3FrameDuration(track, trackMp3)
-		subCondFlushID3FrameSpotifyID(track, trackMp3)
-		subCondFlushID3FrameLyrics(track, trackMp3)
-		trackMp3.Save()
-	}
-	trackMp3.Close()
-}
-
-func subCondFlushID3FrameTitle(track spttb_track.Track, trackMp3 *id3.Tag) {
+			trackTags.Clear()
+		}
+		subCondFlushID3FrameTitle(track, trackTags)
+		subCondFlushID3FrameSong(track, trackTags)
+		subCondFlushID3FrameArtist(track, trackTags)
+		subCondFlushID3FrameAlbum(track, trackTags)
+		subCondFlushID3FrameGenre(track, trackTags)
+		subCondFlushID3FrameYear(track, trackTags)
+		subCondFlushID3FrameFeaturings(track, trackTags)
+		subCondFlushID3FrameTrackNumber(track, trackTags)
+		subCondFlushID3FrameTrackTotals(track, trackTags)
+		subCondFlushID3FrameAlbumArtist(track, trackTags)
+		subCondFlushID3FrameDiscNumber(track, trackTags)
+		subCondFlushID3FrameISRC(track, trackTags)
+		subCondFlushID3FrameArtwork(track, trackTags)
+		subCondFlushID3FrameArtworkURL(track, trackTags)
+		subCondFlushID3FrameYouTubeURL(track, trackTags)
+		subCondFlushID3FrameDuration(track, trackTags)
+		subCondFlushID3FrameSpotifyID(track, trackTags)
+		subCondFlushID3FrameVerification(track, trackTags)
+		subCondFlushID3FrameQuality(track, trackTags)
+		subCondFlushID3FrameReplayGain(track, trackTags)
+		subCondFlushID3FrameLyrics(track, trackTags)
+		subCondFlushID3FrameSyncedLyrics(track, trackTags)
+		subCondFlushID3FrameLyricsTransliteration(track, trackTags)
+		if err := trackTags.Save(); err != nil {
+			gui.WarnAppend(fmt.Sprintf("Something bad happened while saving metadata: %s", err.Error()), spttb_gui.PanelRight)
+		}
+		trackTags.Close()
+	}
+}
+
+func subCondFlushID3FrameTitle(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Title) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameTitle))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameTitle) != track.Title)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameTitle))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameTitle) != track.Title)) {
 		gui.DebugAppend("Inflating title metadata...", spttb_gui.PanelRight)
-		trackMp3.SetTitle(track.Title)
+		trackTags.Set(spttb_track.ID3FrameTitle, track.Title)
 	}
 }
 
-func subCondFlushID3FrameSong(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameSong(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Song) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameSong))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameSong) != track.Song)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameSong))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameSong) != track.Song)) {
 		gui.DebugAppend("Inflating song metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "song",
-			Text:        track.Song,
-		})
+		trackTags.Set(spttb_track.ID3FrameSong, track.Song)
 	}
 }
 
-func subCondFlushID3FrameArtist(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameArtist(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Artist) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameArtist))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameArtist) != track.Artist)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameArtist))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameArtist) != track.Artist)) {
 		gui.DebugAppend("Inflating artist metadata...", spttb_gui.PanelRight)
-		trackMp3.SetArtist(track.Artist)
+		trackTags.Set(spttb_track.ID3FrameArtist, track.Artist)
 	}
 }
 
-func subCondFlushID3FrameAlbum(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameAlbum(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Album) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameAlbum))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameAlbum) != track.Album)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameAlbum))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameAlbum) != track.Album)) {
 		gui.DebugAppend("Inflating album metadata...", spttb_gui.PanelRight)
-		trackMp3.SetAlbum(track.Album)
+		trackTags.Set(spttb_track.ID3FrameAlbum, track.Album)
 	}
 }
 
-func subCondFlushID3FrameGenre(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameGenre(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Genre) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameGenre))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameGenre) != track.Genre)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameGenre))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameGenre) != track.Genre)) {
 		gui.DebugAppend("Inflating genre metadata...", spttb_gui.PanelRight)
-		trackMp3.SetGenre(track.Genre)
+		trackTags.Set(spttb_track.ID3FrameGenre, track.Genre)
 	}
 }
 
-func subCondFlushID3FrameYear(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameYear(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Year) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameYear))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameYear) != track.Year)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameYear))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameYear) != track.Year)) {
 		gui.DebugAppend("Inflating year metadata...", spttb_gui.PanelRight)
-		trackMp3.SetYear(track.Year)
+		trackTags.Set(spttb_track.ID3FrameYear, track.Year)
 	}
 }
 
-func subCondFlushID3FrameFeaturings(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameFeaturings(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Featurings) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameFeaturings))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameFeaturings) != strings.Join(track.Featurings, "|"))) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameFeaturings))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameFeaturings) != strings.Join(track.Featurings, "|"))) {
 		gui.DebugAppend("Inflating featurings metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "featurings",
-			Text:        strings.Join(track.Featurings, "|"),
-		})
+		trackTags.Set(spttb_track.ID3FrameFeaturings, strings.Join(track.Featurings, "|"))
 	}
 }
 
-func subCondFlushID3FrameTrackNumber(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameTrackNumber(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if track.TrackNumber > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameTrackNumber))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameTrackNumber) != fmt.Sprintf("%d", track.TrackNumber))) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameTrackNumber))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameTrackNumber) != fmt.Sprintf("%d", track.TrackNumber))) {
 		gui.DebugAppend("Inflating track number metadata...", spttb_gui.PanelRight)
-		trackMp3.AddFrame(trackMp3.CommonID("Track number/Position in set"),
-			id3.TextFrame{
-				Encoding: id3.EncodingUTF8,
-				Text:     strconv.Itoa(track.TrackNumber),
-			})
+		trackTags.Set(spttb_track.ID3FrameTrackNumber, strconv.Itoa(track.TrackNumber))
 	}
 }
 
-func subCondFlushID3FrameTrackTotals(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameTrackTotals(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if track.TrackTotals > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameTrackTotals))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameTrackTotals) != fmt.Sprintf("%d", track.TrackTotals))) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameTrackTotals))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameTrackTotals) != fmt.Sprintf("%d", track.TrackTotals))) {
 		gui.DebugAppend("Inflating total tracks number metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "trackTotals",
-			Text:        fmt.Sprintf("%d", track.TrackTotals),
-		})
+		trackTags.Set(spttb_track.ID3FrameTrackTotals, fmt.Sprintf("%d", track.TrackTotals))
+	}
+}
+
+func subCondFlushID3FrameAlbumArtist(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.AlbumArtist) > 0 &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameAlbumArtist))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameAlbumArtist) != track.AlbumArtist)) {
+		gui.DebugAppend("Inflating album artist metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameAlbumArtist, track.AlbumArtist)
 	}
 }
 
-func subCondFlushID3FrameArtwork(track spttb_track.Track, trackMp3 *id3.Tag) {
-	if spttb_system.FileExists(track.FilenameArtwork()) &&
-		(!*argFlushMissing || (*argFlushMissing && !track.HasID3Frame(spttb_track.ID3FrameArtwork))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameArtworkURL) != track.Image)) {
-		trackArtworkReader, trackArtworkErr := ioutil.ReadFile(track.FilenameArtwork())
+func subCondFlushID3FrameDiscNumber(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if track.DiscNumber > 0 &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameDiscNumber))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameDiscNumber) != strconv.Itoa(track.DiscNumber))) {
+		gui.DebugAppend("Inflating disc number metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameDiscNumber, strconv.Itoa(track.DiscNumber))
+	}
+}
+
+func subCondFlushID3FrameISRC(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.ISRC) > 0 &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameISRC))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameISRC) != track.ISRC)) {
+		gui.DebugAppend("Inflating ISRC metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameISRC, track.ISRC)
+	}
+}
+
+func subCondFlushID3FrameArtwork(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if artworkCache.Has(track.Image) &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameArtwork))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameArtworkURL) != track.Image)) {
+		trackArtwork, trackArtworkMime, trackArtworkErr := artworkCache.Fetch(track.Image)
 		if trackArtworkErr != nil {
 			gui.WarnAppend(fmt.Sprintf("Unable to read artwork file: %s", trackArtworkErr.Error()), spttb_gui.PanelRight)
 		} else {
 			gui.DebugAppend("Inflating artwork metadata...", spttb_gui.PanelRight)
-			trackMp3.AddAttachedPicture(id3.PictureFrame{
-				Encoding:    id3.EncodingUTF8,
-				MimeType:    "image/jpeg",
-				PictureType: id3.PTFrontCover,
-				Description: "Front cover",
-				Picture:     trackArtworkReader,
-			})
+			trackTags.SetArtwork(trackArtworkMime, trackArtwork)
 		}
 	}
 }
 
-func subCondFlushID3FrameArtworkURL(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameArtworkURL(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Image) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameArtworkURL))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameArtworkURL) != track.Image)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameArtworkURL))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameArtworkURL) != track.Image)) {
 		gui.DebugAppend("Inflating artwork url metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "artwork",
-			Text:        track.Image,
-		})
+		trackTags.Set(spttb_track.ID3FrameArtworkURL, track.Image)
 	}
 }
 
-func subCondFlushID3FrameYouTubeURL(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameYouTubeURL(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.URL) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameYouTubeURL))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameYouTubeURL) != track.URL)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameYouTubeURL))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameYouTubeURL) != track.URL)) {
 		gui.DebugAppend("Inflating youtube origin url metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "youtube",
-			Text:        track.URL,
-		})
+		trackTags.Set(spttb_track.ID3FrameYouTubeURL, track.URL)
 	}
 }
 
-func subCondFlushID3FrameDuration(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameDuration(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if track.Duration > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameDuration))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameDuration) != fmt.Sprintf("%d", track.Duration))) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameDuration))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameDuration) != fmt.Sprintf("%d", track.Duration))) {
 		gui.DebugAppend("Inflating duration metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "duration",
-			Text:        fmt.Sprintf("%d", track.Duration),
-		})
+		trackTags.Set(spttb_track.ID3FrameDuration, fmt.Sprintf("%d", track.Duration))
 	}
 }
 
-func subCondFlushID3FrameSpotifyID(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameSpotifyID(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.SpotifyID) > 0 &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameSpotifyID))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameSpotifyID) != track.SpotifyID)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameSpotifyID))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameSpotifyID) != track.SpotifyID)) {
 		gui.DebugAppend("Inflating Spotify ID metadata...", spttb_gui.PanelRight)
-		trackMp3.AddCommentFrame(id3.CommentFrame{
-			Encoding:    id3.EncodingUTF8,
-			Language:    "eng",
-			Description: "spotifyid",
-			Text:        track.SpotifyID,
-		})
+		trackTags.Set(spttb_track.ID3FrameSpotifyID, track.SpotifyID)
+	}
+}
+
+func subCondFlushID3FrameVerification(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.Verification) > 0 &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameVerification))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameVerification) != track.Verification)) {
+		gui.DebugAppend("Inflating verification metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameVerification, track.Verification)
 	}
 }
 
-func subCondFlushID3FrameLyrics(track spttb_track.Track, trackMp3 *id3.Tag) {
+func subCondFlushID3FrameQuality(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.Quality) > 0 &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameQuality))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameQuality) != track.Quality)) {
+		gui.DebugAppend("Inflating quality metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameQuality, track.Quality)
+	}
+}
+
+func subCondFlushID3FrameReplayGain(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	for _, frame := range []struct {
+		ID          int
+		Description string
+		Value       string
+	}{
+		{spttb_track.ID3FrameTrackGain, spttb_track.ReplayGainTrackGain, track.TrackGain},
+		{spttb_track.ID3FrameTrackPeak, spttb_track.ReplayGainTrackPeak, track.TrackPeak},
+		{spttb_track.ID3FrameAlbumGain, spttb_track.ReplayGainAlbumGain, track.AlbumGain},
+		{spttb_track.ID3FrameAlbumPeak, spttb_track.ReplayGainAlbumPeak, track.AlbumPeak},
+	} {
+		if len(frame.Value) > 0 &&
+			(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(frame.ID))) &&
+			(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(frame.ID) != frame.Value)) {
+			gui.DebugAppend(fmt.Sprintf("Inflating %s metadata...", frame.Description), spttb_gui.PanelRight)
+			trackTags.Set(frame.ID, frame.Value)
+		}
+	}
+}
+
+func subCondFlushID3FrameLyrics(track spttb_track.Track, trackTags *spttb_track.Tags) {
 	if len(track.Lyrics) > 0 && !*argDisableLyrics &&
-		(!*argFlushMissing || (*argFlushMissing && !spttb_track.TagHasFrame(trackMp3, spttb_track.ID3FrameLyrics))) &&
-		(!*argFlushDifferent || (*argFlushDifferent && spttb_track.TagGetFrame(trackMp3, spttb_track.ID3FrameLyrics) != track.Lyrics)) {
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameLyrics))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameLyrics) != track.Lyrics)) {
 		gui.DebugAppend("Inflating lyrics metadata...", spttb_gui.PanelRight)
-		trackMp3.AddUnsynchronisedLyricsFrame(id3.UnsynchronisedLyricsFrame{
-			Encoding:          id3.EncodingUTF8,
-			Language:          "eng",
-			ContentDescriptor: track.Title,
-			Lyrics:            track.Lyrics,
-		})
+		trackTags.Set(spttb_track.ID3FrameLyrics, track.Lyrics)
+	}
+}
+
+func subCondFlushID3FrameSyncedLyrics(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.SyncedLyrics) > 0 && !*argDisableLyrics &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameSyncedLyrics))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameSyncedLyrics) != track.SyncedLyrics)) {
+		gui.DebugAppend("Inflating synced lyrics metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameSyncedLyrics, track.SyncedLyrics)
+	}
+}
+
+func subCondFlushID3FrameLyricsTransliteration(track spttb_track.Track, trackTags *spttb_track.Tags) {
+	if len(track.LyricsTransliteration) > 0 && !*argDisableLyrics &&
+		(!*argFlushMissing || (*argFlushMissing && !trackTags.Has(spttb_track.ID3FrameLyricsTransliteration))) &&
+		(!*argFlushDifferent || (*argFlushDifferent && trackTags.Get(spttb_track.ID3FrameLyricsTransliteration) != track.LyricsTransliteration)) {
+		gui.DebugAppend("Inflating transliterated lyrics metadata...", spttb_gui.PanelRight)
+		trackTags.Set(spttb_track.ID3FrameLyricsTransliteration, track.LyricsTransliteration)
+	}
+}
+
+func subCondLyricsSidecar(track spttb_track.Track) {
+	if *argLyricsSidecar && len(track.SyncedLyrics) > 0 && !*argDisableLyrics {
+		gui.DebugAppend(fmt.Sprintf("Saving song \"%s\" synced lyrics sidecar...", track.Filename), spttb_gui.PanelRight)
+		if err := ioutil.WriteFile(track.FilenameLyrics(), []byte(track.SyncedLyrics+"\n"), 0644); err != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to save synced lyrics sidecar: %s", err.Error()), spttb_gui.PanelRight)
+		}
 	}
 }
 
 func subIfSongSearch(track spttb_track.Track) bool {
-	return !track.Local || *argReplaceLocal || *argSimulate
+	return !track.Local || (*argReplaceLocal && subIfQualityUpgrade(track)) || *argSimulate ||
+		tracksFixes[track.SpotifyID].Mode == spttb_track.FixModeRedownload
+}
+
+func subIfSongReplace(track spttb_track.Track) bool {
+	return *argReplaceLocal || tracksFixes[track.SpotifyID].Mode == spttb_track.FixModeRedownload
+}
+
+func subIfQualityUpgrade(track spttb_track.Track) bool {
+	if *argQualityThreshold <= 0 {
+		return true
+	}
+	quality, qualityErr := spttb_audio.ParseQuality(track.Quality)
+	return qualityErr != nil || quality.Bitrate < *argQualityThreshold
+}
+
+func subCondSongLoudness(track *spttb_track.Track) {
+	if *argDisableNormalization || *argNormalization != spttb_audio.NormalizationReplayGain {
+		return
+	}
+	gui.DebugAppend(fmt.Sprintf("Measuring \"%s\" loudness...", track.Filename), spttb_gui.PanelRight)
+	loudness, loudnessErr := spttb_audio.MeasureLoudness(track.FilenameTemporary())
+	if loudnessErr != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to measure song loudness: %s.", loudnessErr.Error()), spttb_gui.PanelRight)
+		return
+	}
+	track.TrackGain, track.TrackPeak = loudness.Gain(), loudness.Peak()
+	track.AlbumGain, track.AlbumPeak = "", ""
+}
+
+func subCondAlbumGain() {
+	if *argDisableNormalization || *argNormalization != spttb_audio.NormalizationReplayGain || *argSimulate {
+		return
+	}
+
+	var albums = make(map[string]spttb_track.Tracks)
+	for _, track := range tracks {
+		if len(track.Album) > 0 && spttb_system.FileExists(track.FilenameFinal()) {
+			albumKey := fmt.Sprintf("%s (%s)", track.Album, track.Year)
+			albums[albumKey] = append(albums[albumKey], track)
+		}
+	}
+
+	for albumKey, albumTracks := range albums {
+		var (
+			albumPaths      []string
+			albumLoudnesses []spttb_audio.Loudness
+			albumDurations  []float64
+			albumComplete   = true
+		)
+		for _, track := range albumTracks {
+			trackTags, trackTagsErr := spttb_track.LoadTags(track.FilenameFinal(), false)
+			if trackTagsErr != nil {
+				gui.WarnAppend(fmt.Sprintf("Something bad happened while opening: %s", trackTagsErr.Error()), spttb_gui.PanelRight)
+				continue
+			}
+			trackTags.Close()
+			loudness, loudnessErr := spttb_audio.LoudnessFromReplayGain(
+				trackTags.Get(spttb_track.ID3FrameTrackGain),
+				trackTags.Get(spttb_track.ID3FrameTrackPeak))
+			if loudnessErr != nil {
+				gui.DebugAppend(fmt.Sprintf("Measuring \"%s\" loudness...", track.Filename), spttb_gui.PanelRight)
+				if loudness, loudnessErr = spttb_audio.MeasureLoudness(track.FilenameFinal()); loudnessErr != nil {
+					gui.WarnAppend(fmt.Sprintf("Unable to use ffmpeg to measure \"%s\" loudness: %s.", track.Filename, loudnessErr.Error()), spttb_gui.PanelRight)
+					continue
+				}
+				albumComplete = false
+			}
+			if !trackTags.Has(spttb_track.ID3FrameAlbumGain) {
+				albumComplete = false
+			}
+			albumPaths = append(albumPaths, track.FilenameFinal())
+			albumLoudnesses = append(albumLoudnesses, loudness)
+			albumDurations = append(albumDurations, float64(track.Duration))
+		}
+		if albumComplete || len(albumPaths) == 0 {
+			continue
+		}
+
+		albumLoudness := spttb_audio.AlbumLoudness(albumLoudnesses, albumDurations)
+		gui.DebugAppend(fmt.Sprintf("Album \"%s\" gain is %s.", albumKey, albumLoudness.Gain()), spttb_gui.PanelRight)
+		for albumPathIndex, albumPath := range albumPaths {
+			trackTags, trackTagsErr := spttb_track.LoadTags(albumPath, true)
+			if trackTagsErr != nil {
+				gui.WarnAppend(fmt.Sprintf("Something bad happened while opening: %s", trackTagsErr.Error()), spttb_gui.PanelRight)
+				continue
+			}
+			trackTags.Set(spttb_track.ID3FrameTrackGain, albumLoudnesses[albumPathIndex].Gain())
+			trackTags.Set(spttb_track.ID3FrameTrackPeak, albumLoudnesses[albumPathIndex].Peak())
+			trackTags.Set(spttb_track.ID3FrameAlbumGain, albumLoudness.Gain())
+			trackTags.Set(spttb_track.ID3FrameAlbumPeak, albumLoudness.Peak())
+			if err := trackTags.Save(); err != nil {
+				gui.WarnAppend(fmt.Sprintf("Something bad happened while saving metadata: %s", err.Error()), spttb_gui.PanelRight)
+			}
+			trackTags.Close()
+		}
+	}
+}
+
+func subCondQualityEstimate(track *spttb_track.Track) {
+	if !track.Local || !*argReplaceLocal || *argQualityThreshold <= 0 || len(track.Quality) > 0 {
+		return
+	}
+	if quality, qualityErr := spttb_audio.EstimateQuality(track.FilenameFinal()); qualityErr == nil {
+		track.Quality = quality.String()
+	}
 }
 
 func subFetchGob(path string) (spttb_track.TracksDump, error) {
@@ -1048,6 +2361,11 @@ func subCountSongs() (int, int, int) {
 		songsFlush = songsFetch
 	} else if *argFlushMetadata {
 		songsFetch = tracks.CountOnline()
+		for _, track := range tracks {
+			if track.Local && tracksFixes[track.SpotifyID].Mode == spttb_track.FixModeRedownload {
+				songsFetch++
+			}
+		}
 		songsFlush = len(tracks)
 	} else {
 		songsFetch = tracks.CountOnline()
@@ -1067,9 +2385,9 @@ func subMatchResult(track spttb_track.Track, youTubeTrack spttb_youtube.Track) (
 	ansAutomated = bool(ansErr == nil)
 	if *argInteractive {
 		ansInput = gui.PromptInput(fmt.Sprintf("Do you want to download the following video for \"%s\"?\n"+
-			"ID: %s\nTitle: %s\nUser: %s\nDuration: %d\nURL: %s\nScore: %d/6\nResult is matching: %s",
+			"ID: %s\nTitle: %s\nUser: %s\nDuration: %d\nURL: %s\nScore: %d/6\nQuery: %s\nResult is matching: %s",
 			track.Filename, youTubeTrack.ID, youTubeTrack.Title, youTubeTrack.User,
-			youTubeTrack.Duration, youTubeTrack.URL, youTubeTrack.AffinityScore,
+			youTubeTrack.Duration, youTubeTrack.URL, youTubeTrack.AffinityScore, youTubeTrack.Strategy,
 			strconv.FormatBool(ansAutomated)), spttb_gui.OptionNil)
 	}
 	return ansAutomated, ansInput
@@ -1086,6 +2404,8 @@ func subCondManualInputURL(youTubeTrack *spttb_youtube.Track) {
 			if err := spttb_youtube.ValidateURL(inputURL); err == nil {
 				youTubeTrack.Title = "input video"
 				youTubeTrack.URL = inputURL
+				youTubeTrack.ID = spttb_youtube.IDFromURL(inputURL)
+				youTubeTrack.Strategy = spttb_youtube.QueryStrategyManual
 			} else {
 				gui.Prompt(fmt.Sprintf("Something went wrong: %s", err.Error()), spttb_gui.PromptDismissable)
 			}
@@ -1094,7 +2414,7 @@ func subCondManualInputURL(youTubeTrack *spttb_youtube.Track) {
 }
 
 func subIfSongProcess(track spttb_track.Track) bool {
-	return !track.Local || *argFlushMetadata || *argReplaceLocal
+	return !track.Local || *argFlushMetadata || subIfSongReplace(track)
 }
 
 func subCondSequentialDo(track *spttb_track.Track) {
@@ -1108,40 +2428,64 @@ func subCondLyricsFetch(track *spttb_track.Track) {
 	if !*argDisableLyrics &&
 		(!*argFlushMissing || (*argFlushMissing && !track.HasID3Frame(spttb_track.ID3FrameLyrics))) {
 		gui.DebugAppend(fmt.Sprintf("Fetching song \"%s\" lyrics...", track.Filename), spttb_gui.PanelRight)
-		lyricsErr := track.SearchLyrics()
+		lyricsErr := track.SearchLyrics(lyricsCache)
 		if lyricsErr != nil {
 			gui.WarnAppend(fmt.Sprintf("Something went wrong while searching for song lyrics: %s", lyricsErr.Error()), spttb_gui.PanelRight)
 		} else {
 			gui.DebugAppend(fmt.Sprintf("Song lyrics found."), spttb_gui.PanelRight)
 		}
 	}
+	if !*argDisableLyrics && *argSyncedLyrics &&
+		(!*argFlushMissing || (*argFlushMissing && !track.HasID3Frame(spttb_track.ID3FrameSyncedLyrics))) {
+		gui.DebugAppend(fmt.Sprintf("Fetching song \"%s\" synced lyrics...", track.Filename), spttb_gui.PanelRight)
+		lyricsErr := track.SearchSyncedLyrics()
+		if lyricsErr != nil {
+			gui.WarnAppend(fmt.Sprintf("Something went wrong while searching for song synced lyrics: %s", lyricsErr.Error()), spttb_gui.PanelRight)
+		} else {
+			gui.DebugAppend(fmt.Sprintf("Song synced lyrics found."), spttb_gui.PanelRight)
+		}
+	}
+	if !*argDisableLyrics && *argLyricsTransliterate && len(track.Lyrics) > 0 {
+		if transliteration := spttb_track.TransliterateLyrics(track.Lyrics); transliteration != track.Lyrics {
+			track.LyricsTransliteration = transliteration
+		}
+	}
 }
 
 func subCondArtworkDownload(track *spttb_track.Track) {
-	if !spttb_system.FileExists(track.FilenameArtwork()) &&
+	if len(track.Image) > 0 && !artworkCache.Has(track.Image) &&
 		(!*argFlushMissing || (*argFlushMissing && !track.HasID3Frame(spttb_track.ID3FrameArtwork))) {
 		gui.DebugAppend(fmt.Sprintf("Downloading song \"%s\" artwork at %s...", track.Filename, track.Image), spttb_gui.PanelRight)
-		var commandOut bytes.Buffer
-		commandCmd := "ffmpeg"
-		commandArgs := []string{"-i", track.Image, "-q:v", "1", "-n", track.FilenameArtwork()}
-		commandObj := exec.Command(commandCmd, commandArgs...)
-		commandObj.Stderr = &commandOut
-		if err := commandObj.Run(); err != nil {
-			gui.WarnAppend(fmt.Sprintf("Unable to download artwork file \"%s\": %s", track.Image, commandOut.String()), spttb_gui.PanelRight)
+		if _, _, err := artworkCache.Fetch(track.Image); err != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to download artwork file \"%s\": %s", track.Image, err.Error()), spttb_gui.PanelRight)
 		}
 	}
 }
 
+func subCondFolderCover(track spttb_track.Track) {
+	if !*argFolderCover || len(track.Image) == 0 || filepath.Dir(track.FilenameFinal()) == "." {
+		return
+	}
+	if written, err := artworkCache.WriteCover(track.Image, filepath.Dir(track.FilenameFinal())); err != nil {
+		gui.WarnAppend(fmt.Sprintf("Unable to write \"%s\" folder cover: %s", filepath.Dir(track.FilenameFinal()), err.Error()), spttb_gui.PanelRight)
+	} else if written > 0 {
+		gui.DebugAppend(fmt.Sprintf("Folder cover written into \"%s\".", filepath.Dir(track.FilenameFinal())), spttb_gui.PanelRight)
+	}
+}
+
 func subCondTimestampFlush() {
 	if !*argDisableTimestampFlush {
 		gui.Append("Flushing files timestamps...", spttb_gui.PanelRight)
 		now := time.Now().Local().Add(time.Duration(-1*len(tracks)) * time.Minute)
 		for _, track := range tracks {
-			if !spttb_system.FileExists(track.FilenameFinal()) {
+			info, err := os.Stat(track.FilenameFinal())
+			if err != nil {
 				continue
 			}
 			if err := os.Chtimes(track.FilenameFinal(), now, now); err != nil {
 				gui.WarnAppend(fmt.Sprintf("Unable to flush timestamp on %s", track.FilenameFinal()), spttb_gui.PanelRight)
+			} else if touchedInfo, err := os.Stat(track.FilenameFinal()); err == nil && len(track.SpotifyID) > 0 {
+				tracksIndex.Touch(track.SpotifyID, info.ModTime(), touchedInfo.ModTime())
 			}
 			now = now.Add(1 * time.Minute)
 		}
@@ -1151,7 +2495,7 @@ func subCondTimestampFlush() {
 func subCondPlaylistFileWrite() {
 	if !*argSimulate && !*argDisablePlaylistFile && *argPlaylist != "none" {
 		var (
-			playlistFolder  = sanitize.Name(playlistInfo.Name)
+			playlistFolder  = filepath.Join(spttb_system.PlaylistsFolder, sanitize.Name(playlistInfo.Name))
 			playlistFname   = fmt.Sprintf("%s/%s", playlistFolder, playlistInfo.Name)
 			playlistContent string
 		)
@@ -1162,17 +2506,18 @@ func subCondPlaylistFileWrite() {
 			playlistFname = playlistFname + ".pls"
 		}
 
-		os.RemoveAll(playlistFolder)
-		os.Mkdir(playlistFolder, 0775)
-		os.Chdir(playlistFolder)
+		if err := subPlaylistFolderClean(playlistFolder); err != nil {
+			gui.WarnAppend(fmt.Sprintf("Unable to refresh playlist folder %s: %s", playlistFolder, err.Error()), spttb_gui.PanelRight)
+			return
+		}
+		spttb_system.Mkdir(playlistFolder)
 		for _, track := range tracks {
-			if spttb_system.FileExists("../" + track.FilenameFinal()) {
-				if err := os.Symlink("../"+track.FilenameFinal(), track.FilenameFinal()); err != nil {
+			if spttb_system.FileExists(track.FilenameFinal()) {
+				if err := os.Symlink(filepath.Join("..", "..", track.FilenameFinal()), filepath.Join(playlistFolder, track.FilenamePlaylist())); err != nil {
 					gui.ErrAppend(fmt.Sprintf("Unable to create symlink for \"%s\" in %s: %s", track.FilenameFinal(), playlistFolder, err.Error()), spttb_gui.PanelRight)
 				}
 			}
 		}
-		os.Chdir("..")
 
 		gui.Append(fmt.Sprintf("Creating playlist file at %s...", playlistFname), spttb_gui.PanelRight)
 		if spttb_system.FileExists(playlistFname) {
@@ -1184,8 +2529,8 @@ func subCondPlaylistFileWrite() {
 			for trackIndex := len(tracks) - 1; trackIndex >= 0; trackIndex-- {
 				track := tracks[trackIndex]
 				if spttb_system.FileExists(track.FilenameFinal()) {
-					playlistContent += "#EXTINF:" + strconv.Itoa(track.Duration) + "," + track.Filename + "\n" +
-						"./" + track.FilenameFinal() + "\n"
+					playlistContent += "#EXTINF:" + strconv.Itoa(track.Duration) + "," + filepath.Base(track.Filename) + "\n" +
+						"./" + track.FilenamePlaylist() + "\n"
 				}
 			}
 		} else {
@@ -1198,8 +2543,8 @@ func subCondPlaylistFileWrite() {
 				track := tracks[trackIndex]
 				trackInvertedIndex := len(tracks) - trackIndex
 				if spttb_system.FileExists(track.FilenameFinal()) {
-					playlistContent += "File" + strconv.Itoa(trackInvertedIndex) + "=./" + track.FilenameFinal() + "\n" +
-						"Title" + strconv.Itoa(trackInvertedIndex) + "=" + track.Filename + "\n" +
+					playlistContent += "File" + strconv.Itoa(trackInvertedIndex) + "=./" + track.FilenamePlaylist() + "\n" +
+						"Title" + strconv.Itoa(trackInvertedIndex) + "=" + filepath.Base(track.Filename) + "\n" +
 						"Length" + strconv.Itoa(trackInvertedIndex) + "=" + strconv.Itoa(track.Duration) + "\n\n"
 				}
 			}
@@ -1220,24 +2565,51 @@ func subCondPlaylistFileWrite() {
 	}
 }
 
+func subPlaylistFolderClean(playlistFolder string) error {
+	entries, err := ioutil.ReadDir(playlistFolder)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Mode()&os.ModeSymlink == 0 &&
+			filepath.Ext(entry.Name()) != ".m3u" && filepath.Ext(entry.Name()) != ".pls" {
+			return fmt.Errorf(fmt.Sprintf("folder holds \"%s\", which is neither a symlink nor a playlist file", entry.Name()))
+		}
+	}
+	return os.RemoveAll(playlistFolder)
+}
+
+func subJunkPaths() []string {
+	return spttb_system.StaleWorkspaces(*argWorkspace)
+}
+
 func subCleanJunks() int {
 	var removedJunks int
-	for _, junkType := range spttb_track.JunkWildcards() {
-		junkPaths, err := filepath.Glob(junkType)
-		if err != nil {
-			continue
-		}
-		for _, junkPath := range junkPaths {
-			os.Remove(junkPath)
+	for _, junkPath := range subJunkPaths() {
+		if err := os.RemoveAll(junkPath); err == nil {
 			removedJunks++
 		}
 	}
 	return removedJunks
 }
 
+func subRecoverWorkspaces() {
+	for _, stalePath := range spttb_system.StaleWorkspaces(*argWorkspace) {
+		if *argSimulate {
+			gui.Append(fmt.Sprintf("I would like to recover stale workspace \"%s\", but I'm just simulating.", stalePath), spttb_gui.PanelRight)
+		} else if *argDiscardWorkspaces {
+			os.RemoveAll(stalePath)
+			gui.Append(fmt.Sprintf("Stale workspace \"%s\" discarded.", stalePath), spttb_gui.PanelRight)
+		} else {
+			resumed := workspace.Adopt(stalePath, spttb_track.ResumableSuffixes)
+			gui.Append(fmt.Sprintf("Stale workspace \"%s\" recovered: %d files resumed.", stalePath, resumed), spttb_gui.PanelRight)
+		}
+	}
+}
+
 func subSafeExit() {
-	fmt.Println("Signal captured: cleaning up temporary files...")
-	junks := subCleanJunks()
-	fmt.Println(fmt.Sprintf("Cleaned up %d files. Exiting.", junks))
+	fmt.Println("Signal captured: cleaning up workspace...")
 	mainExit()
 }
@@ -201,6 +201,27 @@ func (spotify *Spotify) RemovePlaylistTracks(playlistURI string, ids []api.ID) e
 	return nil
 }
 
+// Track : return Spotify FullTrack identified by input Spotify ID
+func (spotify *Spotify) Track(id string) (api.FullTrack, error) {
+	track, err := spotify.Client.GetTrack(api.ID(id))
+	if err != nil {
+		return api.FullTrack{}, fmt.Errorf(fmt.Sprintf("Something gone wrong while fetching track %s: %s.", id, err.Error()))
+	}
+	return *track, nil
+}
+
+// SearchTracks : return array of Spotify FullTrack matching input query
+func (spotify *Spotify) SearchTracks(query string) ([]api.FullTrack, error) {
+	result, err := spotify.Client.Search(query, api.SearchTypeTrack)
+	if err != nil {
+		return []api.FullTrack{}, fmt.Errorf(fmt.Sprintf("Something gone wrong while searching for \"%s\": %s.", query, err.Error()))
+	}
+	if result.Tracks == nil {
+		return []api.FullTrack{}, nil
+	}
+	return result.Tracks.Tracks, nil
+}
+
 // Albums : return array Spotify FullAlbum, specular to the array of Spotify ID
 func (spotify *Spotify) Albums(ids []api.ID) ([]api.FullAlbum, error) {
 	var (
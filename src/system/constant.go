@@ -15,6 +15,14 @@ const (
 
 	// SongExtension : default downloaded songs extension
 	SongExtension = ".mp3"
+	// SongExtensionFLAC : FLAC songs extension
+	SongExtensionFLAC = ".flac"
+	// SongExtensionOpus : Opus songs extension
+	SongExtensionOpus = ".opus"
+	// SongExtensionM4A : M4A songs extension
+	SongExtensionM4A = ".m4a"
+	// SongExtensionOgg : Ogg Vorbis songs extension
+	SongExtensionOgg = ".ogg"
 	// TCPCheckOrigin : default internet connection check origin
 	TCPCheckOrigin = "github.com:443"
 	// HTTPTimeout : default timeout for HTTP calls
@@ -28,4 +36,14 @@ const (
 	SystemLetterIdxMask = 1<<SystemLetterIdxBits - 1
 	// SystemLetterIdxMax : random string generator max
 	SystemLetterIdxMax = 63 / SystemLetterIdxBits
+
+	// WorkspaceFolder : default workspaces root, relative to the music folder, so that songs get atomically moved out of it
+	WorkspaceFolder = ".spotitube"
+	// WorkspacePrefix : prefix every per-run workspace folder name starts with
+	WorkspacePrefix = "run-"
+	// WorkspaceLock : file, inside every workspace, holding the PID of the process owning it
+	WorkspaceLock = "owner.pid"
+
+	// PlaylistsFolder : playlists folders root, relative to the music folder, kept apart from artists and albums folders
+	PlaylistsFolder = "Playlists"
 )
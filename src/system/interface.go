@@ -5,10 +5,12 @@ import (
 	"encoding/gob"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -31,6 +33,21 @@ func (flag *PathsArrayFlag) Set(value string) error {
 	return nil
 }
 
+// String : string representation for StringsArrayFlag object
+func (flag *StringsArrayFlag) String() string {
+	return fmt.Sprint(flag.Values)
+}
+
+// Set : set value of a StringsArrayFlag object
+func (flag *StringsArrayFlag) Set(value string) error {
+	for _, value := range strings.Split(value, ";") {
+		if value = strings.TrimSpace(value); len(value) > 0 {
+			flag.Values = append(flag.Values, value)
+		}
+	}
+	return nil
+}
+
 // Dir : return True if input string path is a directory
 func Dir(path string) bool {
 	file, err := os.Open(path)
@@ -55,6 +72,15 @@ func Mkdir(dir string) error {
 	return nil
 }
 
+// DirPrune : remove input path parent directories, from the innermost one, as long as they are empty
+func DirPrune(path string) {
+	for dir := filepath.Dir(path); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
+
 // MakeRange : return a range array between input int(s) min and max
 func MakeRange(min, max int) []int {
 	a := make([]int, max-min+1)
@@ -167,6 +193,92 @@ func DumpGob(filePath string, object interface{}) error {
 	return err
 }
 
+// NewWorkspace : create a new per-run workspace into input root folder, owned by current process
+func NewWorkspace(root string) (*Workspace, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	if err := Mkdir(root); err != nil {
+		return nil, err
+	}
+	path, err := ioutil.TempDir(root, fmt.Sprintf("%s%d-", WorkspacePrefix, time.Now().Unix()))
+	if err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(path, WorkspaceLock), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+		os.RemoveAll(path)
+		return nil, err
+	}
+	return &Workspace{Root: root, Path: path}, nil
+}
+
+// StaleWorkspaces : return workspaces into input root folder whose owning process is not running anymore
+func StaleWorkspaces(root string) []string {
+	var staleWorkspaces []string
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		return staleWorkspaces
+	}
+	for _, entry := range entries {
+		path := filepath.Join(root, entry.Name())
+		if entry.IsDir() && strings.HasPrefix(entry.Name(), WorkspacePrefix) && !processAlive(workspaceOwner(path)) {
+			staleWorkspaces = append(staleWorkspaces, path)
+		}
+	}
+	return staleWorkspaces
+}
+
+// File : return input filename path inside Workspace
+func (workspace Workspace) File(filename string) string {
+	return filepath.Join(workspace.Path, filename)
+}
+
+// Adopt : move files from input stale workspace, whose name ends with any of input suffixes, into Workspace, then discard it
+func (workspace Workspace) Adopt(path string, suffixes []string) int {
+	var adopted int
+	entries, _ := ioutil.ReadDir(path)
+	for _, entry := range entries {
+		for _, suffix := range suffixes {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), suffix) {
+				if err := FileMove(filepath.Join(path, entry.Name()), workspace.File(entry.Name())); err == nil {
+					adopted++
+				}
+				break
+			}
+		}
+	}
+	os.RemoveAll(path)
+	return adopted
+}
+
+// Close : remove Workspace, along with every file it still holds
+func (workspace Workspace) Close() error {
+	err := os.RemoveAll(workspace.Path)
+	if filepath.Base(workspace.Root) == WorkspaceFolder {
+		os.Remove(workspace.Root)
+	}
+	return err
+}
+
+// FileMove : move file from input string pathFrom to input string pathTo, copying it whenever they're on different filesystems,
+// next to pathTo first, so that it gets atomically replaced and never gets lost, whatever happens
+func FileMove(pathFrom string, pathTo string) error {
+	if err := fileRename(pathFrom, pathTo); err == nil {
+		return nil
+	}
+	pathPart := pathTo + ".part"
+	if err := FileCopy(pathFrom, pathPart); err != nil {
+		os.Remove(pathPart)
+		return err
+	}
+	if err := fileRename(pathPart, pathTo); err != nil {
+		os.Remove(pathPart)
+		return err
+	}
+	return os.Remove(pathFrom)
+}
+
 // FetchGob : load previously dumped object from filePath to given object
 func FetchGob(filePath string, object interface{}) error {
 	file, err := os.Open(filePath)
@@ -0,0 +1,137 @@
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+)
+
+func TestStaleWorkspaces(t *testing.T) {
+	root, err := ioutil.TempDir("", "workspaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	for name, owner := range map[string]string{
+		WorkspacePrefix + "dead":     "99999999",
+		WorkspacePrefix + "alive":    strconv.Itoa(os.Getpid()),
+		WorkspacePrefix + "unlocked": "",
+		"music":                      "99999999",
+	} {
+		os.MkdirAll(filepath.Join(root, name), 0755)
+		if len(owner) > 0 {
+			ioutil.WriteFile(filepath.Join(root, name, WorkspaceLock), []byte(owner), 0644)
+		}
+	}
+	staleWorkspaces := StaleWorkspaces(root)
+	if len(staleWorkspaces) != 2 ||
+		staleWorkspaces[0] != filepath.Join(root, WorkspacePrefix+"dead") || staleWorkspaces[1] != filepath.Join(root, WorkspacePrefix+"unlocked") {
+		t.Errorf("StaleWorkspaces() = %v, expected dead and unlocked ones", staleWorkspaces)
+	}
+	if staleWorkspaces := StaleWorkspaces(filepath.Join(root, "missing")); len(staleWorkspaces) != 0 {
+		t.Errorf("StaleWorkspaces() on missing root = %v, expected none", staleWorkspaces)
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "workspaces")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, WorkspaceFolder)
+
+	workspace, err := NewWorkspace(root)
+	if err != nil {
+		t.Fatalf("NewWorkspace() unexpected error: %s", err.Error())
+	}
+	if owner, _ := ioutil.ReadFile(filepath.Join(workspace.Path, WorkspaceLock)); string(owner) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("workspace lock = %q, expected current PID", owner)
+	}
+	if staleWorkspaces := StaleWorkspaces(root); len(staleWorkspaces) != 0 {
+		t.Errorf("running workspace expected not to be stale, got %v", staleWorkspaces)
+	}
+
+	stalePath := filepath.Join(root, WorkspacePrefix+"stale")
+	os.MkdirAll(stalePath, 0755)
+	for _, filename := range []string{".halo.stream", ".halo.stream.origin", ".halo.mp3", ".halo.stream.part", WorkspaceLock} {
+		ioutil.WriteFile(filepath.Join(stalePath, filename), []byte(filename), 0644)
+	}
+	if adopted := workspace.Adopt(stalePath, []string{".stream", ".stream.origin"}); adopted != 2 {
+		t.Errorf("Adopt() = %d, expected 2 resumed files", adopted)
+	}
+	for filename, expected := range map[string]bool{".halo.stream": true, ".halo.stream.origin": true, ".halo.mp3": false, ".halo.stream.part": false} {
+		if _, err := os.Stat(workspace.File(filename)); (err == nil) != expected {
+			t.Errorf("adopted %s presence = %v, expected %v", filename, err == nil, expected)
+		}
+	}
+	if owner, _ := ioutil.ReadFile(workspace.File(WorkspaceLock)); string(owner) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("workspace lock expected not to be replaced by the stale one, got %q", owner)
+	}
+	if _, err := os.Stat(stalePath); !os.IsNotExist(err) {
+		t.Errorf("adopted workspace expected to be removed")
+	}
+
+	other, err := NewWorkspace(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := workspace.Close(); err != nil {
+		t.Errorf("Close() unexpected error: %s", err.Error())
+	}
+	if _, err := os.Stat(workspace.Path); !os.IsNotExist(err) {
+		t.Errorf("closed workspace expected to be removed")
+	}
+	if _, err := os.Stat(other.Path); err != nil {
+		t.Errorf("other workspaces expected to be kept on Close()")
+	}
+	other.Close()
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		t.Errorf("empty workspaces root expected to be removed on last Close()")
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Errorf("music folder expected to be kept on Close()")
+	}
+}
+
+func TestFileMove(t *testing.T) {
+	defer func(rename func(string, string) error) { fileRename = rename }(fileRename)
+	dir, err := ioutil.TempDir("", "move")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var (
+		pathFrom = filepath.Join(dir, "workspace", ".halo.mp3")
+		pathTo   = filepath.Join(dir, "Beyonce - Halo.mp3")
+	)
+	os.MkdirAll(filepath.Dir(pathFrom), 0755)
+
+	fileRename = func(from string, to string) error {
+		if from == pathFrom {
+			return &os.LinkError{Op: "rename", Old: from, New: to, Err: syscall.EXDEV}
+		}
+		return os.Rename(from, to)
+	}
+	ioutil.WriteFile(pathFrom, []byte("retagged"), 0644)
+	ioutil.WriteFile(pathTo, []byte("original"), 0644)
+	if err := FileMove(pathFrom, pathTo); err != nil {
+		t.Fatalf("FileMove() across filesystems unexpected error: %s", err.Error())
+	}
+	if content, _ := ioutil.ReadFile(pathTo); string(content) != "retagged" {
+		t.Errorf("moved file content = %q, expected \"retagged\"", content)
+	}
+	if FileExists(pathFrom) || FileExists(pathTo+".part") {
+		t.Errorf("FileMove() expected to leave neither source nor partial copies behind")
+	}
+
+	if err := FileMove(pathFrom, pathTo); err == nil {
+		t.Errorf("FileMove() of a missing file expected to fail")
+	}
+	if content, _ := ioutil.ReadFile(pathTo); string(content) != "retagged" {
+		t.Errorf("failed FileMove() expected to leave destination untouched, got %q", content)
+	}
+}
@@ -4,3 +4,14 @@ package system
 type PathsArrayFlag struct {
 	Paths []string
 }
+
+// Workspace : per-run working directory every intermediate file gets written into
+type Workspace struct {
+	Root string
+	Path string
+}
+
+// StringsArrayFlag : struct containing all the informations about a parsed StringsArrayFlag input flag
+type StringsArrayFlag struct {
+	Values []string
+}
@@ -1 +1,12 @@
 package system
+
+import (
+	"os"
+)
+
+var (
+	// SongExtensions : array containing every supported songs extension
+	SongExtensions = []string{SongExtension, SongExtensionFLAC, SongExtensionOpus, SongExtensionM4A, SongExtensionOgg}
+	// fileRename : function renaming files, failing whenever they're on different filesystems
+	fileRename = os.Rename
+)
@@ -3,10 +3,18 @@ package track
 const (
 	// GeniusAccessToken : Genius app access token
 	GeniusAccessToken = ":GENIUS_TOKEN:"
+	// GeniusAccessTokenLength : length of valid Genius app access tokens
+	GeniusAccessTokenLength = 64
 	// LyricsGeniusAPIURL : lyrics Genius API URL
 	LyricsGeniusAPIURL = "https://api.genius.com/search?q=%s+%s"
 	// LyricsOVHAPIURL : lyrics OVH API URL
 	LyricsOVHAPIURL = "https://api.lyrics.ovh/v1/%s/%s"
+	// LyricsGeniusSelector : Genius page lyrics containers selector
+	LyricsGeniusSelector = "div[data-lyrics-container=true]"
+	// LyricsGeniusExclusionSelector : Genius lyrics containers children selector, for items not being lyrics
+	LyricsGeniusExclusionSelector = "[data-exclude-from-selection=true]"
+	// LyricsLRCLibAPIURL : synced lyrics LRCLIB API URL
+	LyricsLRCLibAPIURL = "https://lrclib.net/api/get?artist_name=%s&track_name=%s&album_name=%s&duration=%d"
 
 	// SongTypeAlbum : identifier for Song in its album variant
 	SongTypeAlbum = iota
@@ -55,4 +63,173 @@ const (
 	ID3FrameDuration
 	// ID3FrameSpotifyID : ID3 Spotify ID frame tag identifier
 	ID3FrameSpotifyID
+	// ID3FrameVerification : ID3 download verification frame tag identifier
+	ID3FrameVerification
+	// ID3FrameQuality : ID3 estimated audio quality frame tag identifier
+	ID3FrameQuality
+	// ID3FrameTrackGain : ID3 ReplayGain track gain frame tag identifier
+	ID3FrameTrackGain
+	// ID3FrameTrackPeak : ID3 ReplayGain track peak frame tag identifier
+	ID3FrameTrackPeak
+	// ID3FrameAlbumGain : ID3 ReplayGain album gain frame tag identifier
+	ID3FrameAlbumGain
+	// ID3FrameAlbumPeak : ID3 ReplayGain album peak frame tag identifier
+	ID3FrameAlbumPeak
+	// ID3FrameAlbumArtist : ID3 album artist frame tag identifier
+	ID3FrameAlbumArtist
+	// ID3FrameDiscNumber : ID3 disc number frame tag identifier
+	ID3FrameDiscNumber
+	// ID3FrameISRC : ID3 ISRC frame tag identifier
+	ID3FrameISRC
+	// ID3FrameSyncedLyrics : ID3 synchronised lyrics frame tag identifier
+	ID3FrameSyncedLyrics
+	// ID3FrameLyricsTransliteration : ID3 transliterated lyrics frame tag identifier
+	ID3FrameLyricsTransliteration
+)
+
+const (
+	// NormalizeSeparators : symbols considered words separators while normalizing sequences
+	NormalizeSeparators = "-_/\\.,:;()[]{}<>|+&~\"="
+
+	// VersionPolicyStrict : version has to be either in both track and candidate, or in none of them
+	VersionPolicyStrict = "strict"
+	// VersionPolicyRequire : version has to be in candidate whenever track has it
+	VersionPolicyRequire = "require"
+	// VersionPolicyReject : version cannot be in candidate whenever track has not it
+	VersionPolicyReject = "reject"
+	// VersionPolicyIgnore : version gets stripped from titles, without affecting matching
+	VersionPolicyIgnore = "ignore"
+
+	// VersionLive : live version rule name
+	VersionLive = "live"
+	// VersionCover : cover version rule name
+	VersionCover = "cover"
+	// VersionRemix : remix version rule name
+	VersionRemix = "remix"
+	// VersionAcoustic : acoustic version rule name
+	VersionAcoustic = "acoustic"
+	// VersionKaraoke : karaoke version rule name
+	VersionKaraoke = "karaoke"
+	// VersionParody : parody version rule name
+	VersionParody = "parody"
+	// VersionReverse : reverse version rule name
+	VersionReverse = "reverse"
+	// VersionRemaster : remaster version rule name
+	VersionRemaster = "remaster"
+	// VersionRadioEdit : radio edit version rule name
+	VersionRadioEdit = "radio edit"
+	// VersionExtended : extended mix version rule name
+	VersionExtended = "extended"
+	// VersionSpedUp : sped up version rule name
+	VersionSpedUp = "sped up"
+	// VersionSlowed : slowed and reverb version rule name
+	VersionSlowed = "slowed"
+	// VersionNightcore : nightcore version rule name
+	VersionNightcore = "nightcore"
+	// Version8D : 8D audio version rule name
+	Version8D = "8d"
+	// VersionDemo : demo version rule name
+	VersionDemo = "demo"
+	// VersionMono : mono version rule name
+	VersionMono = "mono"
+
+	// ReplayGainTrackGain : ReplayGain track gain user defined text frame description
+	ReplayGainTrackGain = "REPLAYGAIN_TRACK_GAIN"
+	// ReplayGainTrackPeak : ReplayGain track peak user defined text frame description
+	ReplayGainTrackPeak = "REPLAYGAIN_TRACK_PEAK"
+	// ReplayGainAlbumGain : ReplayGain album gain user defined text frame description
+	ReplayGainAlbumGain = "REPLAYGAIN_ALBUM_GAIN"
+	// ReplayGainAlbumPeak : ReplayGain album peak user defined text frame description
+	ReplayGainAlbumPeak = "REPLAYGAIN_ALBUM_PEAK"
+
+	// TagFormatID3 : ID3v2 tags format, used by mp3 songs
+	TagFormatID3 = "id3"
+	// TagFormatVorbis : Vorbis comments tags format, used by flac, opus and ogg songs
+	TagFormatVorbis = "vorbis"
+	// TagFormatMP4 : MP4 atoms tags format, used by m4a songs
+	TagFormatMP4 = "mp4"
+	// TagVorbisPictureKey : Vorbis comment key artwork gets stored into
+	TagVorbisPictureKey = "METADATA_BLOCK_PICTURE"
+	// TagMP4CommentKey : MP4 atom custom frames get packed into, one "description=value" per line
+	TagMP4CommentKey = "comment"
+	// TagUFIDOwner : owner identifier of the UFID frame Spotify ID gets stored into
+	TagUFIDOwner = "https://open.spotify.com"
+	// TagPictureFrontCover : front cover picture type, as defined by ID3v2 APIC and FLAC PICTURE
+	TagPictureFrontCover = 3
+	// TagSYLTTimestampMilliseconds : SYLT frame timestamp format, expressing absolute milliseconds
+	TagSYLTTimestampMilliseconds = 2
+	// TagSYLTContentLyrics : SYLT frame content type, marking it as lyrics
+	TagSYLTContentLyrics = 1
+
+	// IndexVersion : Index format version, bumped whenever its records change
+	IndexVersion = 2
+	// LyricsCacheVersion : LyricsCacheEntry format version, bumped whenever cached lyrics need to be searched again
+	// (1: lyrics kept in their native script)
+	LyricsCacheVersion = 1
+	// IndexScanWorkers : songs read concurrently while scanning Index
+	IndexScanWorkers = 8
+
+	// AuditNoSpotifyID : audit category of songs without a Spotify ID
+	AuditNoSpotifyID = "no-spotify-id"
+	// AuditMissingArtwork : audit category of songs without an artwork
+	AuditMissingArtwork = "missing-artwork"
+	// AuditMissingLyrics : audit category of songs without lyrics
+	AuditMissingLyrics = "missing-lyrics"
+	// AuditMissingGenre : audit category of songs without a genre
+	AuditMissingGenre = "missing-genre"
+	// AuditDurationMismatch : audit category of songs whose decoded duration differs from the stored one
+	AuditDurationMismatch = "duration-mismatch"
+	// AuditDuplicateSpotifyID : audit category of songs sharing their Spotify ID with other ones
+	AuditDuplicateSpotifyID = "duplicate-spotify-id"
+	// AuditDuplicateISRC : audit category of songs sharing their ISRC with other ones
+	AuditDuplicateISRC = "duplicate-isrc"
+	// AuditBrokenSymlink : audit category of playlists symlinks pointing to missing songs
+	AuditBrokenSymlink = "broken-symlink"
+	// AuditJunk : audit category of leftover junk files
+	AuditJunk = "junk"
+	// AuditUndecodable : audit category of songs whose tags or audio cannot be decoded
+	AuditUndecodable = "undecodable"
+
+	// ImportConfidenceThreshold : minimum confidence a Spotify match gets imported with, without asking for confirmation
+	ImportConfidenceThreshold = 0.8
+	// ImportConfidenceMinimum : minimum confidence a Spotify match gets even proposed with
+	ImportConfidenceMinimum = 0.4
+	// ImportDurationTolerance : max difference between local and Spotify durations, to be still considered similar
+	ImportDurationTolerance = 10 // second(s)
+
+	// ArtworkMIMEJPEG : JPEG artwork MIME type
+	ArtworkMIMEJPEG = "image/jpeg"
+	// ArtworkMIMEPNG : PNG artwork MIME type
+	ArtworkMIMEPNG = "image/png"
+	// ArtworkJPEGQuality : quality artworks get JPEG encoded with, whenever resized or converted
+	ArtworkJPEGQuality = 90
+
+	// FixModeRedownload : fix mode replacing song audio, along with its metadata
+	FixModeRedownload = "redownload"
+	// FixModeMetadata : fix mode just flushing song metadata, keeping its audio
+	FixModeMetadata = "metadata"
+
+	// PathTemplateDefault : default songs path template, placing them flat into the working folder
+	PathTemplateDefault = "{artist} - {title}"
+	// PathUnknown : path component used whenever its template renders empty
+	PathUnknown = "Unknown"
+	// PathComponentMaxLength : path component maximum runes length, leaving room for extensions on FAT/exFAT
+	PathComponentMaxLength = 200
+
+	// LyricsSidecarExtension : synced lyrics sidecar file extension
+	LyricsSidecarExtension = ".lrc"
+	// LyricsLanguageUndetermined : ISO 639-2 language code lyrics frames get stored with, if not detected
+	LyricsLanguageUndetermined = "und"
+	// LyricsLanguageMinMatches : minimum stopwords matches needed to detect a Latin script lyrics language
+	LyricsLanguageMinMatches = 3
+	// LyricsTransliterationDescriptor : content descriptor of the lyrics frame transliterated lyrics get stored into
+	LyricsTransliterationDescriptor = "transliteration"
+	// LyricsProviderGenius : Genius lyrics provider name
+	LyricsProviderGenius = "genius"
+	// LyricsProviderOVH : lyrics.ovh lyrics provider name
+	LyricsProviderOVH = "ovh"
+	// LyricsProviderLRCLib : LRCLIB lyrics provider name
+	LyricsProviderLRCLib = "lrclib"
+	// LyricsNegativeCacheTTL : hours a not found lyrics outcome is cached for, before being searched again
+	LyricsNegativeCacheTTL = 24 * 7
 )
@@ -1,41 +1,251 @@
 package track
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	spttb_system "system"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/agnivade/levenshtein"
+	"github.com/bogem/id3v2"
+	"github.com/bradfitz/slice"
 	"github.com/kennygrant/sanitize"
 	"github.com/mozillazg/go-unidecode"
+	"github.com/zmb3/spotify"
+	"golang.org/x/text/unicode/norm"
 )
 
-func parseType(sequence string) int {
+func parseType(trackVersions []string) int {
 	for _, songType := range SongTypes {
-		if SeemsType(sequence, songType) {
+		if containsString(trackVersions, SongTypeVersions[songType]) {
 			return songType
 		}
 	}
 	return SongTypeAlbum
 }
 
-func parseTitle(trackTitle string, trackFeaturings []string) (string, string) {
-	var trackSong string
+func parseVersion(trackTitle string) (string, []string, []string) {
+	var (
+		trackVersions    []string
+		trackVersionTags []string
+		titleSegments    = splitTitle(trackTitle)
+	)
+	appendVersion := func(versionTag string, versions []string) {
+		trackVersionTags = append(trackVersionTags, strings.TrimSpace(versionTag))
+		for _, version := range versions {
+			if !containsString(trackVersions, version) {
+				trackVersions = append(trackVersions, version)
+			}
+		}
+	}
+
+	trackTitle = titleSegments[0]
+	for _, titleSegment := range titleSegments[1:] {
+		if versions := matchVersions(titleSegment); len(versions) > 0 {
+			appendVersion(titleSegment, versions)
+		}
+	}
+	trackTitle = versionGroupPattern.ReplaceAllStringFunc(trackTitle, func(group string) string {
+		groupContent := versionGroupPattern.FindStringSubmatch(group)[1]
+		if isFeaturingGroup(groupContent) {
+			return group
+		}
+		if versions := matchVersions(groupContent); len(versions) > 0 {
+			appendVersion(groupContent, versions)
+			return ""
+		}
+		return group
+	})
+
+	return strings.TrimSpace(trackTitle), trackVersionTags, trackVersions
+}
+
+func splitTitle(trackTitle string) []string {
+	var (
+		titleSegments []string
+		depth         int
+		last          int
+	)
+	for i := 0; i < len(trackTitle); i++ {
+		switch trackTitle[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		case '-':
+			if depth == 0 && i > 0 && i < len(trackTitle)-1 && trackTitle[i-1] == ' ' && trackTitle[i+1] == ' ' {
+				titleSegments = append(titleSegments, strings.TrimSpace(trackTitle[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	return append(titleSegments, strings.TrimSpace(trackTitle[last:]))
+}
+
+func matchVersions(sequence string, exclusions ...string) []string {
+	var (
+		versions       []string
+		normalized     = " " + Normalize(sequence) + " "
+		transliterated = " " + Transliterate(sequence) + " "
+	)
+	for _, exclusion := range exclusions {
+		if exclusionNormalized := Normalize(exclusion); len(exclusionNormalized) > 0 {
+			normalized = strings.Replace(normalized, " "+exclusionNormalized+" ", " ", -1)
+		}
+		if exclusionTransliterated := Transliterate(exclusion); len(exclusionTransliterated) > 0 {
+			transliterated = strings.Replace(transliterated, " "+exclusionTransliterated+" ", " ", -1)
+		}
+	}
+	for _, rule := range ActiveVersionRules {
+		for _, alias := range rule.Aliases {
+			aliasNormalized := Normalize(alias)
+			if (len(aliasNormalized) == 0 && len(alias) > 0 && strings.Contains(strings.ToLower(sequence), strings.ToLower(alias))) ||
+				(len(aliasNormalized) > 0 && (strings.Contains(normalized, " "+aliasNormalized+" ") ||
+					strings.Contains(transliterated, " "+Transliterate(alias)+" "))) {
+				versions = append(versions, rule.Name)
+				break
+			}
+		}
+	}
+	return versions
+}
+
+func versionPolicy(version string) string {
+	for _, rule := range ActiveVersionRules {
+		if rule.Name == version {
+			return rule.Policy
+		}
+	}
+	return VersionPolicyIgnore
+}
+
+func isFeaturingGroup(sequence string) bool {
+	sequence = strings.ToLower(strings.TrimSpace(sequence))
+	for _, featuringSymbol := range []string{"featuring ", "feat. ", "feat ", "ft. ", "ft ", "with "} {
+		if strings.HasPrefix(sequence, featuringSymbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseUntaggedFilename(filename string) (string, string) {
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	name = strings.TrimSpace(trackNumberPattern.ReplaceAllString(name, ""))
+	if nameParts := strings.SplitN(name, " - ", 2); len(nameParts) == 2 {
+		return strings.TrimSpace(nameParts[0]), strings.TrimSpace(nameParts[1])
+	}
+	return "", name
+}
 
-	if !(strings.Contains(trackTitle, " (") && strings.Contains(strings.Split(strings.Split(trackTitle, ")")[0], "(")[1], " - ")) {
-		trackTitle = strings.Split(trackTitle, " - ")[0]
+func largestImage(images []spotify.Image) string {
+	var (
+		imageURL  string
+		imageArea = -1
+	)
+	for _, image := range images {
+		if image.Width*image.Height > imageArea {
+			imageURL, imageArea = image.URL, image.Width*image.Height
+		}
 	}
-	if strings.Contains(trackTitle, " live ") {
-		trackTitle = strings.Split(trackTitle, " live ")[0]
+	return imageURL
+}
+
+func resizeArtwork(picture []byte, size int) ([]byte, error) {
+	source, _, err := image.Decode(bytes.NewReader(picture))
+	if err != nil {
+		return nil, err
 	}
-	trackTitle = strings.TrimSpace(trackTitle)
+	bounds := source.Bounds()
+	if size <= 0 || (bounds.Dx() <= size && bounds.Dy() <= size) {
+		return picture, nil
+	}
+
+	width, height := size, bounds.Dy()*size/bounds.Dx()
+	if bounds.Dy() > bounds.Dx() {
+		width, height = bounds.Dx()*size/bounds.Dy(), size
+	}
+	width, height = int(math.Max(float64(width), 1)), int(math.Max(float64(height), 1))
+	resized := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		fromY, toY := bounds.Min.Y+y*bounds.Dy()/height, bounds.Min.Y+(y+1)*bounds.Dy()/height
+		for x := 0; x < width; x++ {
+			fromX, toX := bounds.Min.X+x*bounds.Dx()/width, bounds.Min.X+(x+1)*bounds.Dx()/width
+			var r, g, b, a, pixels uint64
+			for sourceY := fromY; sourceY < toY; sourceY++ {
+				for sourceX := fromX; sourceX < toX; sourceX++ {
+					pixelR, pixelG, pixelB, pixelA := source.At(sourceX, sourceY).RGBA()
+					r, g, b, a, pixels = r+uint64(pixelR), g+uint64(pixelG), b+uint64(pixelB), a+uint64(pixelA), pixels+1
+				}
+			}
+			resized.Set(x, y, color.RGBA64{uint16(r / pixels), uint16(g / pixels), uint16(b / pixels), uint16(a / pixels)})
+		}
+	}
+
+	var buffer bytes.Buffer
+	if http.DetectContentType(picture) == ArtworkMIMEPNG {
+		err = png.Encode(&buffer, resized)
+	} else {
+		err = jpeg.Encode(&buffer, resized, &jpeg.Options{Quality: ArtworkJPEGQuality})
+	}
+	return buffer.Bytes(), err
+}
+
+func frameName(frame int) string {
+	if description, ok := tagFrameDescriptions[frame]; ok {
+		return description
+	}
+	return strings.ToLower(tagVorbisKeys[frame])
+}
+
+func similarity(sequence string, other string) float64 {
+	sequence, other = Normalize(sequence), Normalize(other)
+	if len(sequence) == 0 || len(other) == 0 {
+		return 0
+	}
+	length := math.Max(float64(utf8.RuneCountInString(sequence)), float64(utf8.RuneCountInString(other)))
+	return 1 - float64(levenshtein.ComputeDistance(sequence, other))/length
+}
+
+func containsString(items []string, item string) bool {
+	for _, value := range items {
+		if value == item {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTitle(trackTitle string, trackFeaturings []string, trackVersionTags []string) (string, string) {
+	var trackSong string
+
+	trackTitle = splitTitle(trackTitle)[0]
 	if len(trackFeaturings) > 0 {
 		var (
 			featuringsAlreadyParsed bool
@@ -76,6 +286,15 @@ func parseTitle(trackTitle string, trackFeaturings []string) (string, string) {
 		trackSong = trackTitle
 	}
 
+	for _, versionTag := range trackVersionTags {
+		for _, version := range matchVersions(versionTag) {
+			if versionPolicy(version) != VersionPolicyIgnore {
+				trackTitle = fmt.Sprintf("%s (%s)", trackTitle, versionTag)
+				break
+			}
+		}
+	}
+
 	return trackTitle, trackSong
 }
 
@@ -83,11 +302,83 @@ func parseFilename(track Track) (string, string) {
 	var (
 		trackFilename     string
 		trackFilenameTemp string
+		trackComponents   []string
 	)
-	trackFilename = track.Artist + " - " + track.Title
-	for _, symbol := range []string{"/", "\\", ".", "?", "<", ">", ":", "*"} {
-		trackFilename = strings.Replace(trackFilename, symbol, "", -1)
+	for _, component := range strings.Split(ActivePathTemplate, "/") {
+		trackComponents = append(trackComponents, sanitizePathComponent(renderPathComponent(component, track)))
+	}
+	trackFilename = strings.Join(trackComponents, "/")
+	trackFilenameTemp = sanitize.Name("." + unidecode.Unidecode(strings.Join(trackComponents, " - ")))
+
+	return trackFilename, trackFilenameTemp
+}
+
+func parseSearchPattern(track Track) string {
+	searchComponents := []string{track.Artist, track.Title}
+	for _, featuring := range track.Featurings {
+		if len(featuring) > 0 && !strings.Contains(Normalize(track.Title), Normalize(featuring)) {
+			searchComponents = append(searchComponents, featuring)
+		}
 	}
+	return Normalize(strings.Join(searchComponents, " "))
+}
+
+func renderPathComponent(component string, track Track) string {
+	return pathTemplatePattern.ReplaceAllStringFunc(component, func(placeholder string) string {
+		var (
+			match = pathTemplatePattern.FindStringSubmatch(placeholder)
+			value string
+		)
+		switch match[1] {
+		case "title":
+			value = track.Title
+		case "song":
+			value = track.Song
+		case "artist":
+			value = track.Artist
+		case "album":
+			value = track.Album
+		case "albumartist":
+			value = track.AlbumArtist
+			if len(value) == 0 {
+				value = track.Artist
+			}
+		case "year":
+			value = track.Year
+		case "genre":
+			value = track.Genre
+		case "featurings":
+			value = strings.Join(track.Featurings, ", ")
+		case "track":
+			value = strconv.Itoa(track.TrackNumber)
+		case "tracktotals":
+			value = strconv.Itoa(track.TrackTotals)
+		case "disc":
+			value = strconv.Itoa(track.DiscNumber)
+		case "spotifyid":
+			value = track.SpotifyID
+		case "isrc":
+			value = track.ISRC
+		}
+		if width, err := strconv.Atoi(match[2]); err == nil {
+			for len(value) < width {
+				value = "0" + value
+			}
+		}
+		return value
+	})
+}
+
+func sanitizePathComponent(component string) string {
+	for _, symbol := range []string{"/", "\\", ".", "?", "<", ">", ":", "*", "\"", "|"} {
+		component = strings.Replace(component, symbol, "", -1)
+	}
+	component = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, component)
 
 	// due to recent sanitize library changes, some umlauts changes
 	// get performed instead of simple accents removal: while waiting for
@@ -99,23 +390,47 @@ func parseFilename(track Track) (string, string) {
 		[]string{"ä", "a"},
 		[]string{"ö", "o"},
 		[]string{"ü", "u"}} {
-		trackFilename = strings.Replace(trackFilename, umlaut[0], umlaut[1], -1)
+		component = strings.Replace(component, umlaut[0], umlaut[1], -1)
 	}
 
-	trackFilename = strings.Replace(trackFilename, "  ", " ", -1)
-	trackFilename = sanitize.Accents(trackFilename)
-	trackFilename = strings.TrimSpace(trackFilename)
-	trackFilenameTemp = sanitize.Name("." + trackFilename)
+	component = strings.Replace(component, "  ", " ", -1)
+	component = sanitize.Accents(component)
+	component = strings.TrimSpace(component)
+	// empty template fields leave their separators dangling
+	component = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(component, "- "), " -"))
+	if runes := []rune(component); len(runes) > PathComponentMaxLength {
+		component = strings.TrimSpace(string(runes[:PathComponentMaxLength]))
+	}
+	if len(strings.Trim(component, " -_")) == 0 {
+		return PathUnknown
+	}
+	if containsString(pathReservedNames, strings.ToUpper(component)) {
+		component += "_"
+	}
+	return component
+}
 
-	return trackFilename, trackFilenameTemp
+func isSignificant(item string) bool {
+	for _, r := range item {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			return utf8.RuneCountInString(item) > 1
+		}
+	}
+	return utf8.RuneCountInString(item) > 3
+}
+
+func geniusToken() string {
+	if geniusToken := os.Getenv("GENIUS_TOKEN"); len(geniusToken) == GeniusAccessTokenLength {
+		return geniusToken
+	} else if len(GeniusAccessToken) == GeniusAccessTokenLength {
+		return GeniusAccessToken
+	}
+	return ""
 }
 
 func searchLyricsGenius(track *Track) (string, error) {
-	var geniusToken = os.Getenv("GENIUS_TOKEN")
+	var geniusToken = geniusToken()
 	if len(geniusToken) == 0 {
-		geniusToken = GeniusAccessToken
-	}
-	if len(GeniusAccessToken) == 0 {
 		return "", fmt.Errorf("Cannot fetch lyrics from Genius without a valid token")
 	}
 
@@ -127,50 +442,68 @@ func searchLyricsGenius(track *Track) (string, error) {
 	if lyricsError != nil {
 		return "", fmt.Errorf("Unable to compile Genius lyrics request: " + lyricsError.Error())
 	}
-	lyricsRequest.Header.Add("Authorization", fmt.Sprintf("Bearer %s", GeniusAccessToken))
+	lyricsRequest.Header.Add("Authorization", fmt.Sprintf("Bearer %s", geniusToken))
 
 	lyricsResponse, lyricsError := lyricsClient.Do(lyricsRequest)
 	if lyricsError != nil {
 		return "", fmt.Errorf("Unable to read Genius lyrics response from lyrics request: " + lyricsError.Error())
 	}
+	defer lyricsResponse.Body.Close()
+	if lyricsResponse.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(fmt.Sprintf("Genius lyrics request failed: %s", lyricsResponse.Status))
+	}
 
 	lyricsResponseBody, lyricsError := ioutil.ReadAll(lyricsResponse.Body)
 	if lyricsError != nil {
 		return "", fmt.Errorf("Unable to get Genius lyrics response body: " + lyricsError.Error())
 	}
 
-	var result map[string]interface{}
-	hitsUnmarshalErr := json.Unmarshal([]byte(lyricsResponseBody), &result)
-	if hitsUnmarshalErr != nil {
-		return "", fmt.Errorf("Unable to unmarshal Genius lyrics content into interface: %s", hitsUnmarshalErr.Error())
+	lyricsURL, lyricsError := parseGeniusSearch(lyricsResponseBody, track)
+	if lyricsError != nil || len(lyricsURL) == 0 {
+		return "", lyricsError
 	}
 
-	hits := result["response"].(map[string]interface{})["hits"].([]interface{})
-	var lyricsURL string
-	for _, value := range hits {
-		valueResult := value.(map[string]interface{})["result"].(map[string]interface{})
-		songTitle := strings.TrimSpace(valueResult["title"].(string))
-		songArtist := strings.TrimSpace(valueResult["primary_artist"].(map[string]interface{})["name"].(string))
+	lyricsPage, lyricsError := lyricsClient.Get(lyricsURL)
+	if lyricsError != nil {
+		return "", fmt.Errorf("Unable to read Genius lyrics page: " + lyricsError.Error())
+	}
+	defer lyricsPage.Body.Close()
+	if lyricsPage.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(fmt.Sprintf("Genius lyrics page request failed: %s", lyricsPage.Status))
+	}
+	return parseGeniusLyrics(lyricsPage.Body)
+}
 
-		songErr := track.Seems(fmt.Sprintf("%s %s", songTitle, songArtist))
-		if songErr == nil {
-			lyricsURL = strings.TrimSpace(valueResult["url"].(string))
-			break
+func parseGeniusSearch(body []byte, track *Track) (string, error) {
+	var result geniusSearchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf(fmt.Sprintf("Unable to unmarshal Genius lyrics search: %s", err.Error()))
+	}
+	for _, hit := range result.Response.Hits {
+		songTitle := strings.TrimSpace(hit.Result.Title)
+		songArtist := strings.TrimSpace(hit.Result.PrimaryArtist.Name)
+		if track.Seems(fmt.Sprintf("%s %s", songTitle, songArtist)) == nil {
+			return strings.TrimSpace(hit.Result.URL), nil
 		}
 	}
+	return "", nil
+}
 
-	if len(lyricsURL) == 0 {
-		return "", fmt.Errorf("Genius lyrics not found")
+func parseGeniusLyrics(page io.Reader) (string, error) {
+	document, err := goquery.NewDocumentFromReader(page)
+	if err != nil {
+		return "", fmt.Errorf("Unable to parse Genius lyrics page: " + err.Error())
 	}
-
-	doc, _ := goquery.NewDocument(lyricsURL)
-	return strings.TrimSpace(unidecode.Unidecode(doc.Find(".lyrics").Eq(0).Text())), nil
+	var sections []string
+	document.Find(LyricsGeniusSelector).Each(func(_ int, container *goquery.Selection) {
+		container.Find(LyricsGeniusExclusionSelector).Remove()
+		container.Find("br").ReplaceWithHtml("\n")
+		sections = append(sections, container.Text())
+	})
+	return normalizeLyrics(strings.Join(sections, "\n")), nil
 }
 
 func searchLyricsOvh(track *Track) (string, error) {
-	type LyricsAPIEntry struct {
-		Lyrics string `json:"lyrics"`
-	}
 	lyricsClient := http.Client{
 		Timeout: time.Second * spttb_system.HTTPTimeout,
 	}
@@ -183,15 +516,542 @@ func searchLyricsOvh(track *Track) (string, error) {
 	if lyricsError != nil {
 		return "", fmt.Errorf("Unable to read response from lyrics request: " + lyricsError.Error())
 	}
+	defer lyricsResponse.Body.Close()
+	if lyricsResponse.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
 	lyricsResponseBody, lyricsError := ioutil.ReadAll(lyricsResponse.Body)
 	if lyricsError != nil {
 		return "", fmt.Errorf("Unable to get response body: " + lyricsError.Error())
 	}
+	return parseOVHLyrics(lyricsResponseBody)
+}
+
+func parseOVHLyrics(body []byte) (string, error) {
+	type LyricsAPIEntry struct {
+		Lyrics string `json:"lyrics"`
+	}
 	lyricsData := LyricsAPIEntry{}
-	lyricsError = json.Unmarshal(lyricsResponseBody, &lyricsData)
+	if err := json.Unmarshal(body, &lyricsData); err != nil {
+		return "", fmt.Errorf("Unable to parse json from response body: " + err.Error())
+	}
+	return normalizeLyrics(lyricsData.Lyrics), nil
+}
+
+func searchLyricsLRCLib(track *Track) (string, string, error) {
+	lyricsClient := http.Client{
+		Timeout: time.Second * spttb_system.HTTPTimeout,
+	}
+	lyricsRequest, lyricsError := http.NewRequest(http.MethodGet,
+		fmt.Sprintf(LyricsLRCLibAPIURL, url.QueryEscape(track.Artist), url.QueryEscape(track.Song),
+			url.QueryEscape(track.Album), track.Duration), nil)
 	if lyricsError != nil {
-		return "", fmt.Errorf("Unable to parse json from response body: " + lyricsError.Error())
+		return "", "", fmt.Errorf("Unable to compile LRCLIB lyrics request: " + lyricsError.Error())
+	}
+	lyricsResponse, lyricsError := lyricsClient.Do(lyricsRequest)
+	if lyricsError != nil {
+		return "", "", fmt.Errorf("Unable to read response from LRCLIB lyrics request: " + lyricsError.Error())
+	}
+	defer lyricsResponse.Body.Close()
+	if lyricsResponse.StatusCode == http.StatusNotFound {
+		return "", "", nil
+	} else if lyricsResponse.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf(fmt.Sprintf("LRCLIB lyrics request failed: %s", lyricsResponse.Status))
+	}
+	lyricsResponseBody, lyricsError := ioutil.ReadAll(lyricsResponse.Body)
+	if lyricsError != nil {
+		return "", "", fmt.Errorf("Unable to get LRCLIB response body: " + lyricsError.Error())
+	}
+	return parseLRCLibLyrics(lyricsResponseBody)
+}
+
+func parseLRCLibLyrics(body []byte) (string, string, error) {
+	type LyricsAPIEntry struct {
+		SyncedLyrics string `json:"syncedLyrics"`
+		PlainLyrics  string `json:"plainLyrics"`
+	}
+	lyricsData := LyricsAPIEntry{}
+	if err := json.Unmarshal(body, &lyricsData); err != nil {
+		return "", "", fmt.Errorf("Unable to parse json from LRCLIB response body: " + err.Error())
+	}
+	return normalizeLyrics(lyricsData.SyncedLyrics), normalizeLyrics(lyricsData.PlainLyrics), nil
+}
+
+func readIndexRecord(path string, info os.FileInfo) (string, IndexRecord, error) {
+	tags, err := LoadTags(path, false)
+	if err != nil {
+		return "", IndexRecord{}, err
+	}
+	defer tags.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", IndexRecord{}, err
 	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", IndexRecord{}, err
+	}
+
+	record := IndexRecord{
+		Path:    path,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		Format:  strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), "."),
+		VideoID: parseVideoID(tags.Get(ID3FrameYouTubeURL)),
+		Hash:    hex.EncodeToString(hash.Sum(nil)),
+		Tags: IndexTags{
+			Title:   tags.Get(ID3FrameTitle),
+			Artist:  tags.Get(ID3FrameArtist),
+			Album:   tags.Get(ID3FrameAlbum),
+			Year:    tags.Get(ID3FrameYear),
+			Quality: tags.Get(ID3FrameQuality),
+			Lyrics:  tags.Has(ID3FrameLyrics),
+		},
+	}
+	record.Duration, _ = strconv.Atoi(tags.Get(ID3FrameDuration))
+	return tags.Get(ID3FrameSpotifyID), record, nil
+}
+
+func parseVideoID(url string) string {
+	var id string
+	if parts := strings.SplitN(url, "youtu.be/", 2); len(parts) == 2 {
+		id = parts[1]
+	} else if parts := strings.SplitN(url, "v=", 2); len(parts) == 2 {
+		id = parts[1]
+	}
+	fields := strings.FieldsFunc(id, func(r rune) bool {
+		return r == '?' || r == '&' || r == '#' || r == '/'
+	})
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func normalizeLyrics(lyrics string) string {
+	var (
+		lines []string
+		blank bool
+	)
+	lyrics = strings.Replace(strings.Replace(lyrics, "\r\n", "\n", -1), "\r", "\n", -1)
+	for _, line := range strings.Split(norm.NFC.String(lyrics), "\n") {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if len(line) == 0 && blank {
+			continue
+		}
+		lines, blank = append(lines, line), len(line) == 0
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func detectLatinLanguage(lyrics string) string {
+	var (
+		words    = make(map[string]int)
+		language = LyricsLanguageUndetermined
+		matches  = LyricsLanguageMinMatches - 1
+	)
+	for _, word := range strings.FieldsFunc(strings.ToLower(lyrics), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	}) {
+		words[strings.Replace(word, "’", "'", -1)]++
+	}
+	for _, candidate := range lyricsLanguages {
+		var candidateMatches int
+		for _, stopword := range candidate.Stopwords {
+			candidateMatches += words[stopword]
+		}
+		if candidateMatches > matches {
+			language, matches = candidate.Language, candidateMatches
+		}
+	}
+	return language
+}
+
+func parseLyricsTimestamp(minutes string, seconds string, fraction string) (int, error) {
+	minutesValue, _ := strconv.Atoi(minutes)
+	secondsValue, _ := strconv.Atoi(seconds)
+	if secondsValue >= 60 {
+		return 0, fmt.Errorf(fmt.Sprintf("Invalid seconds value: %s", seconds))
+	}
+	timestamp := (minutesValue*60 + secondsValue) * 1000
+	if len(fraction) > 0 {
+		fractionValue, _ := strconv.Atoi(fraction)
+		for digits := len(fraction); digits < 3; digits++ {
+			fractionValue *= 10
+		}
+		timestamp += fractionValue
+	}
+	return timestamp, nil
+}
+
+func tagFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case spttb_system.SongExtension:
+		return TagFormatID3
+	case spttb_system.SongExtensionFLAC, spttb_system.SongExtensionOpus, spttb_system.SongExtensionOgg:
+		return TagFormatVorbis
+	case spttb_system.SongExtensionM4A:
+		return TagFormatMP4
+	}
+	return ""
+}
+
+func openFFmpegTagger(path string, format string) (*ffmpegTagger, error) {
+	var (
+		commandOut bytes.Buffer
+		probe      ffprobeOutput
+		tagger     = &ffmpegTagger{path: path, format: format, frames: make(map[string]string), comments: make(map[string]string)}
+	)
+	commandCmd := "ffprobe"
+	commandArgs := []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", path}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stdout = &commandOut
+	if commandErr := commandObj.Run(); commandErr != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\": %s", commandCmd, strings.Join(commandArgs, " "), commandErr.Error()))
+	}
+	if err := json.Unmarshal(commandOut.Bytes(), &probe); err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("Unable to parse \"%s\" tags: %s", path, err.Error()))
+	}
+
+	for key, value := range probe.Format.Tags {
+		tagger.frames[strings.ToLower(key)] = value
+	}
+	for _, stream := range probe.Streams {
+		if stream.Disposition.AttachedPic == 1 {
+			tagger.pictureFound = true
+		} else if stream.CodecType == "audio" {
+			for key, value := range stream.Tags {
+				tagger.frames[strings.ToLower(key)] = value
+			}
+		}
+	}
+	if format == TagFormatMP4 {
+		tagger.frames[TagMP4CommentKey], tagger.comments = unpackMP4Comment(tagger.frames[TagMP4CommentKey])
+	}
+	return tagger, nil
+}
+
+func isTagFrameDescription(description string) bool {
+	for _, frameDescription := range tagFrameDescriptions {
+		if frameDescription == description {
+			return true
+		}
+	}
+	for _, frameDescription := range tagMP4PackedKeys {
+		if frameDescription == description {
+			return true
+		}
+	}
+	return false
+}
+
+func packMP4Comment(comment string, comments map[string]string) string {
+	var (
+		commentLines        []string
+		commentDescriptions []string
+	)
+	if len(comment) > 0 {
+		commentLines = append(commentLines, comment)
+	}
+	for description := range comments {
+		commentDescriptions = append(commentDescriptions, description)
+	}
+	slice.Sort(commentDescriptions, func(i, j int) bool {
+		return commentDescriptions[i] < commentDescriptions[j]
+	})
+	for _, description := range commentDescriptions {
+		if len(comments[description]) > 0 {
+			commentLines = append(commentLines, description+"="+mp4CommentEscaper.Replace(comments[description]))
+		}
+	}
+	return strings.Join(commentLines, "\n")
+}
+
+func unpackMP4Comment(comment string) (string, map[string]string) {
+	var (
+		commentLines []string
+		comments     = make(map[string]string)
+	)
+	for _, commentLine := range strings.Split(comment, "\n") {
+		if commentPair := strings.SplitN(commentLine, "=", 2); len(commentPair) == 2 && isTagFrameDescription(commentPair[0]) {
+			comments[commentPair[0]] = mp4CommentUnescaper.Replace(commentPair[1])
+		} else if len(commentLine) > 0 {
+			commentLines = append(commentLines, commentLine)
+		}
+	}
+	return strings.Join(commentLines, "\n"), comments
+}
+
+func extractPicture(path string) ([]byte, error) {
+	var (
+		commandOut bytes.Buffer
+		commandErr bytes.Buffer
+	)
+	commandCmd := "ffmpeg"
+	commandArgs := []string{"-nostdin", "-hide_banner", "-i", path, "-map", "0:v:0", "-c", "copy", "-f", "image2pipe", "-"}
+	commandObj := exec.Command(commandCmd, commandArgs...)
+	commandObj.Stdout = &commandOut
+	commandObj.Stderr = &commandErr
+	if err := commandObj.Run(); err != nil {
+		return nil, fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandErr.String()))
+	}
+	return commandOut.Bytes(), nil
+}
+
+func vorbisPictureBlock(mimeType string, picture []byte) string {
+	var (
+		block       bytes.Buffer
+		description = "Front cover"
+	)
+	binary.Write(&block, binary.BigEndian, uint32(TagPictureFrontCover))
+	binary.Write(&block, binary.BigEndian, uint32(len(mimeType)))
+	block.WriteString(mimeType)
+	binary.Write(&block, binary.BigEndian, uint32(len(description)))
+	block.WriteString(description)
+	// width, height, color depth and indexed colors count are left unspecified
+	binary.Write(&block, binary.BigEndian, [4]uint32{})
+	binary.Write(&block, binary.BigEndian, uint32(len(picture)))
+	block.Write(picture)
+	return base64.StdEncoding.EncodeToString(block.Bytes())
+}
+
+func ffmetadataEscape(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "=", "\\=", ";", "\\;", "#", "\\#", "\n", "\\\n").Replace(value)
+}
+
+func tagGetText(tag *id3v2.Tag, id string) string {
+	return tag.GetTextFrame(id).Text
+}
+
+func tagGetComment(tag *id3v2.Tag, description string) string {
+	for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
+		comment, ok := frameComment.(id3v2.CommentFrame)
+		if ok && comment.Description == description {
+			return comment.Text
+		}
+	}
+	return ""
+}
+
+func tagDeleteComment(tag *id3v2.Tag, description string) {
+	var comments []id3v2.CommentFrame
+	for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
+		if comment, ok := frameComment.(id3v2.CommentFrame); ok && comment.Description != description {
+			comments = append(comments, comment)
+		}
+	}
+	tag.DeleteFrames(tag.CommonID("Comments"))
+	for _, comment := range comments {
+		tag.AddCommentFrame(comment)
+	}
+}
+
+func tagGetUserText(tag *id3v2.Tag, description string) string {
+	for _, frameText := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		text, ok := frameText.(id3v2.UserDefinedTextFrame)
+		if ok && text.Description == description {
+			return text.Value
+		}
+	}
+	return ""
+}
+
+func tagGetUFID(tag *id3v2.Tag, owner string) string {
+	for _, frameUFID := range tag.GetFrames("UFID") {
+		ufid, ok := frameUFID.(id3v2.UFIDFrame)
+		if ok && ufid.OwnerIdentifier == owner {
+			return string(ufid.Identifier)
+		}
+	}
+	return ""
+}
+
+func tagGetURL(tag *id3v2.Tag, id string) string {
+	for _, frameURL := range tag.GetFrames(id) {
+		switch url := frameURL.(type) {
+		case urlFrame:
+			return url.URL
+		case id3v2.UnknownFrame:
+			return string(bytes.TrimRight(url.Body, "\x00"))
+		}
+	}
+	return ""
+}
+
+func tagGetUserURL(tag *id3v2.Tag, description string) string {
+	for _, frameURL := range tag.GetFrames("WXXX") {
+		if urlDescription, url := parseUserURLFrame(frameURL); urlDescription == description {
+			return url
+		}
+	}
+	return ""
+}
+
+func tagSetUserURL(tag *id3v2.Tag, description string, url string) {
+	var urls []userURLFrame
+	for _, frameURL := range tag.GetFrames("WXXX") {
+		if urlDescription, urlValue := parseUserURLFrame(frameURL); urlDescription != description {
+			urls = append(urls, userURLFrame{Description: urlDescription, URL: urlValue})
+		}
+	}
+	tag.DeleteFrames("WXXX")
+	for _, frameURL := range append(urls, userURLFrame{Description: description, URL: url}) {
+		tag.AddFrame("WXXX", frameURL)
+	}
+}
+
+func parseUserURLFrame(frame id3v2.Framer) (string, string) {
+	switch url := frame.(type) {
+	case userURLFrame:
+		return url.Description, url.URL
+	case id3v2.UnknownFrame:
+		if len(url.Body) == 0 {
+			return "", ""
+		}
+		description, body := readTerminated(url.Body[1:], url.Body[0])
+		return description, string(bytes.TrimRight(body, "\x00"))
+	}
+	return "", ""
+}
+
+func readTerminated(body []byte, encoding byte) (string, []byte) {
+	terminator := []byte{0}
+	if encoding == id3v2.EncodingUTF16.Key || encoding == id3v2.EncodingUTF16BE.Key {
+		terminator = []byte{0, 0}
+	}
+	sequence, rest := body, []byte(nil)
+	for i := 0; i+len(terminator) <= len(body); i += len(terminator) {
+		if bytes.Equal(body[i:i+len(terminator)], terminator) {
+			sequence, rest = body[:i], body[i+len(terminator):]
+			break
+		}
+	}
+	if len(terminator) == 2 {
+		sequence = decodeUTF16(sequence)
+	}
+	return string(sequence), rest
+}
+
+func parseSyncedLyricsFrame(frame id3v2.Framer) (syncedLyricsFrame, bool) {
+	switch lyrics := frame.(type) {
+	case syncedLyricsFrame:
+		return lyrics, true
+	case id3v2.UnknownFrame:
+		if len(lyrics.Body) < 6 || lyrics.Body[4] != TagSYLTTimestampMilliseconds {
+			return syncedLyricsFrame{}, false
+		}
+		var (
+			encoding = lyrics.Body[0]
+			parsed   = syncedLyricsFrame{Language: string(lyrics.Body[1:4])}
+			text     string
+		)
+		_, body := readTerminated(lyrics.Body[6:], encoding)
+		for len(body) > 0 {
+			text, body = readTerminated(body, encoding)
+			if len(body) < 4 {
+				break
+			}
+			parsed.Lines = append(parsed.Lines, LyricsLine{Timestamp: int(binary.BigEndian.Uint32(body[:4])), Text: text})
+			body = body[4:]
+		}
+		return parsed, true
+	}
+	return syncedLyricsFrame{}, false
+}
+
+func encodeUTF16(sequence string) []byte {
+	encoded := []byte{0xff, 0xfe}
+	for _, r := range utf16.Encode([]rune(sequence)) {
+		encoded = append(encoded, byte(r), byte(r>>8))
+	}
+	return encoded
+}
+
+func decodeUTF16(sequence []byte) []byte {
+	var (
+		runes     []uint16
+		bigEndian = true
+	)
+	if len(sequence) >= 2 && sequence[0] == 0xff && sequence[1] == 0xfe {
+		bigEndian, sequence = false, sequence[2:]
+	} else if len(sequence) >= 2 && sequence[0] == 0xfe && sequence[1] == 0xff {
+		sequence = sequence[2:]
+	}
+	for i := 0; i+1 < len(sequence); i += 2 {
+		if bigEndian {
+			runes = append(runes, uint16(sequence[i])<<8|uint16(sequence[i+1]))
+		} else {
+			runes = append(runes, uint16(sequence[i+1])<<8|uint16(sequence[i]))
+		}
+	}
+	return []byte(string(utf16.Decode(runes)))
+}
+
+func openLocalTrack(filename string, pictures bool) (Track, bool, error) {
+	if !spttb_system.FileExists(filename) {
+		return Track{}, false, fmt.Errorf(fmt.Sprintf("%s does not exist", filename))
+	}
+	trackTags, err := LoadTags(filename, pictures)
+	if err != nil {
+		return Track{}, false, fmt.Errorf(fmt.Sprintf("Cannot read tags from \"%s\": %s", filename, err.Error()))
+	}
+	track := Track{
+		Title:                 trackTags.Get(ID3FrameTitle),
+		Song:                  trackTags.Get(ID3FrameSong),
+		Artist:                trackTags.Get(ID3FrameArtist),
+		Album:                 trackTags.Get(ID3FrameAlbum),
+		AlbumArtist:           trackTags.Get(ID3FrameAlbumArtist),
+		Year:                  trackTags.Get(ID3FrameYear),
+		Featurings:            strings.Split(trackTags.Get(ID3FrameFeaturings), "|"),
+		Genre:                 trackTags.Get(ID3FrameGenre),
+		TrackNumber:           0,
+		TrackTotals:           0,
+		DiscNumber:            0,
+		Duration:              0,
+		SongType:              SongTypeAlbum,
+		Image:                 trackTags.Get(ID3FrameArtworkURL),
+		Preview:               "",
+		URL:                   trackTags.Get(ID3FrameYouTubeURL),
+		SpotifyID:             trackTags.Get(ID3FrameSpotifyID),
+		ISRC:                  trackTags.Get(ID3FrameISRC),
+		Filename:              "",
+		FilenameTemp:          "",
+		FilenameExt:           filepath.Ext(filename),
+		SearchPattern:         "",
+		Lyrics:                trackTags.Get(ID3FrameLyrics),
+		SyncedLyrics:          trackTags.Get(ID3FrameSyncedLyrics),
+		LyricsTransliteration: trackTags.Get(ID3FrameLyricsTransliteration),
+		Verification:          trackTags.Get(ID3FrameVerification),
+		Quality:               trackTags.Get(ID3FrameQuality),
+		TrackGain:             trackTags.Get(ID3FrameTrackGain),
+		TrackPeak:             trackTags.Get(ID3FrameTrackPeak),
+		AlbumGain:             trackTags.Get(ID3FrameAlbumGain),
+		AlbumPeak:             trackTags.Get(ID3FrameAlbumPeak),
+		Local:                 true,
+	}
+
+	if trackNumber, trackNumberErr := strconv.Atoi(trackTags.Get(ID3FrameTrackNumber)); trackNumberErr == nil {
+		track.TrackNumber = trackNumber
+	}
+	if trackTotals, trackTotalsErr := strconv.Atoi(trackTags.Get(ID3FrameTrackTotals)); trackTotalsErr == nil {
+		track.TrackTotals = trackTotals
+	}
+	if discNumber, discNumberErr := strconv.Atoi(trackTags.Get(ID3FrameDiscNumber)); discNumberErr == nil {
+		track.DiscNumber = discNumber
+	}
+	if duration, durationErr := strconv.Atoi(trackTags.Get(ID3FrameDuration)); durationErr == nil {
+		track.Duration = duration
+	}
+
+	_, track.VersionTags, track.Versions = parseVersion(track.Title)
+	track.SongType = parseType(track.Versions)
+
+	track.Filename, track.FilenameTemp = parseFilename(track)
+
+	track.SearchPattern = parseSearchPattern(track)
 
-	return strings.TrimSpace(unidecode.Unidecode(lyricsData.Lyrics)), nil
+	trackTags.Close()
+	return track, trackTags.Has(ID3FrameArtwork), nil
 }
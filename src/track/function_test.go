@@ -0,0 +1,307 @@
+package track
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zmb3/spotify"
+)
+
+func TestParseFilename(t *testing.T) {
+	for _, fixture := range []struct {
+		track            Track
+		expectedFilename string
+		expectedTemp     string
+	}{
+		{Track{Artist: "Beyoncé", Title: "Halo"}, "Beyonce - Halo", ".beyonce-halo"},
+		{Track{Artist: "Кино", Title: "Группа крови"}, "Кино - Группа крови", ".kino-gruppa-krovi"},
+		{Track{Artist: "방탄소년단", Title: "봄날"}, "방탄소년단 - 봄날", ".bangtansonyeondan-bomnal"},
+		{Track{Artist: "Μίκης Θεοδωράκης", Title: "Ζορμπάς"}, "Μίκης Θεοδωράκης - Ζορμπάς", ".mikes-theodorakes-zormpas"},
+	} {
+		filename, filenameTemp := parseFilename(fixture.track)
+		if filename != fixture.expectedFilename {
+			t.Errorf("parseFilename(%s - %s) filename = %q, expected %q", fixture.track.Artist, fixture.track.Title, filename, fixture.expectedFilename)
+		}
+		if filenameTemp != fixture.expectedTemp {
+			t.Errorf("parseFilename(%s - %s) temporary filename = %q, expected %q", fixture.track.Artist, fixture.track.Title, filenameTemp, fixture.expectedTemp)
+		}
+	}
+}
+
+func TestParseFilenameTemplate(t *testing.T) {
+	defer func(template string) { ActivePathTemplate = template }(ActivePathTemplate)
+	track := Track{Artist: "Beyoncé", AlbumArtist: "Beyoncé", Title: "Halo", Album: "I Am... Sasha Fierce",
+		Year: "2008", TrackNumber: 3, DiscNumber: 1}
+	for _, fixture := range []struct {
+		template         string
+		expectedFilename string
+		expectedTemp     string
+	}{
+		{"{albumartist}/{year} - {album}/{track:2} {title}", "Beyonce/2008 - I Am Sasha Fierce/03 Halo", ".beyonce-2008-i-am-sasha-fierce-03-halo"},
+		{"{artist}/{disc}-{track:3} {title}", "Beyonce/1-003 Halo", ".beyonce-1-003-halo"},
+		{"{genre}/{artist} - {title}", "Unknown/Beyonce - Halo", ".unknown-beyonce-halo"},
+		{"{artist}/{genre} - {album}", "Beyonce/I Am Sasha Fierce", ".beyonce-i-am-sasha-fierce"},
+	} {
+		ActivePathTemplate = fixture.template
+		filename, filenameTemp := parseFilename(track)
+		if filename != fixture.expectedFilename {
+			t.Errorf("parseFilename(%q) filename = %q, expected %q", fixture.template, filename, fixture.expectedFilename)
+		}
+		if filenameTemp != fixture.expectedTemp {
+			t.Errorf("parseFilename(%q) temporary filename = %q, expected %q", fixture.template, filenameTemp, fixture.expectedTemp)
+		}
+	}
+}
+
+func TestSanitizePathComponent(t *testing.T) {
+	for _, fixture := range []struct {
+		component string
+		expected  string
+	}{
+		{"AC/DC - Back In Black", "ACDC - Back In Black"},
+		{"What's \"Love\"? | Tina: Live*", "What's Love Tina Live"},
+		{"Nul", "Nul_"},
+		{"CON", "CON_"},
+		{"Tab\tSeparated", "TabSeparated"},
+		{"...", PathUnknown},
+		{" - ", PathUnknown},
+		{strings.Repeat("a", PathComponentMaxLength+10), strings.Repeat("a", PathComponentMaxLength)},
+	} {
+		if component := sanitizePathComponent(fixture.component); component != fixture.expected {
+			t.Errorf("sanitizePathComponent(%q) = %q, expected %q", fixture.component, component, fixture.expected)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	for _, fixture := range []struct {
+		title            string
+		expectedTitle    string
+		expectedVersions []string
+	}{
+		{"Heroes - 2017 Remaster", "Heroes", []string{VersionRemaster}},
+		{"Here Comes the Sun (Remastered 2009)", "Here Comes the Sun", []string{VersionRemaster}},
+		{"Levels - Radio Edit", "Levels", []string{VersionRadioEdit}},
+		{"Strobe - Extended Mix", "Strobe", []string{VersionExtended}},
+		{"Heat Waves (Sped Up)", "Heat Waves", []string{VersionSpedUp}},
+		{"Somebody That I Used To Know - Slowed + Reverb", "Somebody That I Used To Know", []string{VersionSlowed}},
+		{"Angel of Darkness (Nightcore)", "Angel of Darkness", []string{VersionNightcore}},
+		{"Faded (8D Audio)", "Faded", []string{Version8D}},
+		{"Yellow - Demo", "Yellow", []string{VersionDemo}},
+		{"Paperback Writer - Mono Version", "Paperback Writer", []string{VersionMono}},
+		{"Bohemian Rhapsody - Live At Wembley '86", "Bohemian Rhapsody", []string{VersionLive}},
+		{"Live Forever", "Live Forever", nil},
+		{"Demons (feat. Someone)", "Demons (feat. Someone)", nil},
+		{"Part of Me (Part 2)", "Part of Me (Part 2)", nil},
+	} {
+		title, _, versions := parseVersion(fixture.title)
+		if title != fixture.expectedTitle {
+			t.Errorf("parseVersion(%q) title = %q, expected %q", fixture.title, title, fixture.expectedTitle)
+		}
+		if len(versions) != len(fixture.expectedVersions) {
+			t.Errorf("parseVersion(%q) versions = %v, expected %v", fixture.title, versions, fixture.expectedVersions)
+			continue
+		}
+		for i := range versions {
+			if versions[i] != fixture.expectedVersions[i] {
+				t.Errorf("parseVersion(%q) versions = %v, expected %v", fixture.title, versions, fixture.expectedVersions)
+			}
+		}
+	}
+}
+
+func TestParseTitleVersions(t *testing.T) {
+	for _, fixture := range []struct {
+		title         string
+		featurings    []string
+		expectedTitle string
+		expectedSong  string
+	}{
+		{"Heroes - 2017 Remaster", nil, "Heroes", "Heroes"},
+		{"Heat Waves (Sped Up)", nil, "Heat Waves (Sped Up)", "Heat Waves"},
+		{"Bohemian Rhapsody - Live At Wembley '86", nil, "Bohemian Rhapsody (Live At Wembley '86)", "Bohemian Rhapsody"},
+		{"Strobe - Extended Mix", []string{"Someone"}, "Strobe (ft. Someone) (Extended Mix)", "Strobe"},
+	} {
+		title, versionTags, _ := parseVersion(fixture.title)
+		title, song := parseTitle(title, fixture.featurings, versionTags)
+		if title != fixture.expectedTitle || song != fixture.expectedSong {
+			t.Errorf("parseTitle(%q) = %q, %q, expected %q, %q", fixture.title, title, song, fixture.expectedTitle, fixture.expectedSong)
+		}
+	}
+}
+
+func TestTagFormat(t *testing.T) {
+	for path, expected := range map[string]string{
+		"Artist - Song.mp3":  TagFormatID3,
+		"Artist - Song.FLAC": TagFormatVorbis,
+		"Artist - Song.opus": TagFormatVorbis,
+		"Artist - Song.ogg":  TagFormatVorbis,
+		"Artist - Song.m4a":  TagFormatMP4,
+		"Artist - Song.wav":  "",
+	} {
+		if format := tagFormat(path); format != expected {
+			t.Errorf("tagFormat(%q) = %q, expected %q", path, format, expected)
+		}
+	}
+}
+
+func TestVorbisPictureBlock(t *testing.T) {
+	var (
+		picture = []byte{0xff, 0xd8, 0xff, 0xe0}
+		fields  []uint32
+	)
+	block, err := base64.StdEncoding.DecodeString(vorbisPictureBlock("image/jpeg", picture))
+	if err != nil {
+		t.Fatalf("picture block expected to be base64 encoded: %s", err.Error())
+	}
+	reader := bytes.NewReader(block)
+	for _, skip := range []int{0, 0, len("image/jpeg")} {
+		var field uint32
+		reader.Seek(int64(skip), 1)
+		binary.Read(reader, binary.BigEndian, &field)
+		fields = append(fields, field)
+	}
+	if fields[0] != TagPictureFrontCover || fields[1] != uint32(len("image/jpeg")) || fields[2] != uint32(len("Front cover")) {
+		t.Errorf("picture block header = %v", fields)
+	}
+	if !bytes.HasSuffix(block, picture) || len(block) != 4*8+len("image/jpeg")+len("Front cover")+len(picture) {
+		t.Errorf("picture block expected to end with picture data, length %d", len(block))
+	}
+}
+
+func TestFFmetadataEscape(t *testing.T) {
+	if escaped := ffmetadataEscape("a=b;c#d\\e\nf"); escaped != "a\\=b\\;c\\#d\\\\e\\\nf" {
+		t.Errorf("ffmetadataEscape = %q", escaped)
+	}
+}
+
+func TestParseGeniusSearch(t *testing.T) {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "genius_search.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lyricsURL, err := parseGeniusSearch(body, &Track{Title: "Halo", Song: "Halo", Artist: "Beyoncé"})
+	if err != nil {
+		t.Fatalf("Genius search expected to be parsed: %s", err.Error())
+	}
+	if expected := "https://genius.com/Beyonce-halo-lyrics"; lyricsURL != expected {
+		t.Errorf("parseGeniusSearch() = %q, expected %q", lyricsURL, expected)
+	}
+	if lyricsURL, _ = parseGeniusSearch(body, &Track{Title: "Crazy in Love", Song: "Crazy in Love", Artist: "Beyoncé"}); len(lyricsURL) > 0 {
+		t.Errorf("parseGeniusSearch() = %q, expected no match", lyricsURL)
+	}
+	if _, err = parseGeniusSearch([]byte("<html>"), &Track{}); err == nil {
+		t.Errorf("parseGeniusSearch() expected to fail on malformed body")
+	}
+}
+
+func TestParseGeniusLyrics(t *testing.T) {
+	page, err := os.Open(filepath.Join("testdata", "genius_lyrics.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Close()
+	lyrics, err := parseGeniusLyrics(page)
+	if err != nil {
+		t.Fatalf("Genius lyrics expected to be parsed: %s", err.Error())
+	}
+	expected := "[Verse 1]\nRemember those walls I built?\nWell, baby, they're tumbling down\n" +
+		"And they didn't even put up a fight\nThey didn't even make up a sound\n" +
+		"[Chorus]\nEverywhere I'm looking now\nI'm surrounded by your embrace"
+	if lyrics != expected {
+		t.Errorf("parseGeniusLyrics() = %q, expected %q", lyrics, expected)
+	}
+}
+
+func TestParseOVHLyrics(t *testing.T) {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "ovh_lyrics.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lyrics, err := parseOVHLyrics(body)
+	if err != nil {
+		t.Fatalf("lyrics.ovh lyrics expected to be parsed: %s", err.Error())
+	}
+	expected := "Paroles de la chanson Non, je ne regrette rien par Édith Piaf\nNon, rien de rien\nNon, je ne regrette rien\n\n" +
+		"Ni le bien qu'on m'a fait\nNi le mal, tout ça m'est bien égal"
+	if lyrics != expected {
+		t.Errorf("parseOVHLyrics() = %q, expected %q", lyrics, expected)
+	}
+}
+
+func TestParseLRCLibLyrics(t *testing.T) {
+	body, err := ioutil.ReadFile(filepath.Join("testdata", "lrclib_lyrics.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	syncedLyrics, lyrics, err := parseLRCLibLyrics(body)
+	if err != nil {
+		t.Fatalf("LRCLIB lyrics expected to be parsed: %s", err.Error())
+	}
+	if expected := "[00:17.51] Remember those walls I built?\n[00:21.12] Well, baby, they're tumbling down"; syncedLyrics != expected {
+		t.Errorf("parseLRCLibLyrics() synced lyrics = %q, expected %q", syncedLyrics, expected)
+	}
+	if expected := "Remember those walls I built?\nWell, baby, they're tumbling down"; lyrics != expected {
+		t.Errorf("parseLRCLibLyrics() lyrics = %q, expected %q", lyrics, expected)
+	}
+}
+
+func TestParseUntaggedFilename(t *testing.T) {
+	for filename, expected := range map[string][2]string{
+		"Beyonce - Halo.mp3":                      {"Beyonce", "Halo"},
+		"music/01 - Beyonce - Halo.mp3":           {"Beyonce", "Halo"},
+		"03. Daft Punk - Get Lucky.flac":          {"Daft Punk", "Get Lucky"},
+		"Halo.mp3":                                {"", "Halo"},
+		"1999 - Prince - Little Red Corvette.mp3": {"1999", "Prince - Little Red Corvette"},
+	} {
+		if artist, title := parseUntaggedFilename(filename); artist != expected[0] || title != expected[1] {
+			t.Errorf("parseUntaggedFilename(%q) = %q, %q, expected %q, %q", filename, artist, title, expected[0], expected[1])
+		}
+	}
+}
+
+func TestLargestImage(t *testing.T) {
+	images := []spotify.Image{
+		{Height: 64, Width: 64, URL: "https://i.scdn.co/image/small"},
+		{Height: 640, Width: 640, URL: "https://i.scdn.co/image/large"},
+		{Height: 300, Width: 300, URL: "https://i.scdn.co/image/medium"},
+	}
+	if url := largestImage(images); url != "https://i.scdn.co/image/large" {
+		t.Errorf("largestImage() = %q, expected the 640x640 one", url)
+	}
+	if url := largestImage(nil); url != "" {
+		t.Errorf("largestImage(nil) = %q, expected empty string", url)
+	}
+}
+
+func TestResizeArtwork(t *testing.T) {
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 100, 50))); err != nil {
+		t.Fatal(err)
+	}
+	resized, err := resizeArtwork(picture.Bytes(), 20)
+	if err != nil {
+		t.Fatalf("resizeArtwork() unexpected error: %s", err.Error())
+	}
+	resizedImage, resizedFormat, err := image.Decode(bytes.NewReader(resized))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resizedFormat != "png" {
+		t.Errorf("resizeArtwork() format = %q, expected png", resizedFormat)
+	}
+	if bounds := resizedImage.Bounds(); bounds.Dx() != 20 || bounds.Dy() != 10 {
+		t.Errorf("resizeArtwork() size = %dx%d, expected 20x10", bounds.Dx(), bounds.Dy())
+	}
+	if unchanged, _ := resizeArtwork(picture.Bytes(), 200); !bytes.Equal(unchanged, picture.Bytes()) {
+		t.Errorf("resizeArtwork() expected to leave smaller artworks untouched")
+	}
+}
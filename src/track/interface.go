@@ -1,15 +1,34 @@
 package track
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	spttb_system "system"
 
 	"github.com/bogem/id3v2"
-	"github.com/kennygrant/sanitize"
+	"github.com/bradfitz/slice"
+	"github.com/mozillazg/go-unidecode"
 	"github.com/zmb3/spotify"
+	"golang.org/x/text/unicode/norm"
 )
 
 // CountOffline : return offline (local) songs count from Tracks
@@ -30,52 +49,324 @@ func (tracks Tracks) CountOnline() int {
 
 // OpenLocalTrack : parse local filename track informations into a new Track object
 func OpenLocalTrack(filename string) (Track, error) {
-	if !spttb_system.FileExists(filename) {
-		return Track{}, fmt.Errorf(fmt.Sprintf("%s does not exist", filename))
+	track, _, err := openLocalTrack(filename, false)
+	return track, err
+}
+
+// OpenLocalTrackArtwork : parse local filename track informations into a new Track object, reading its pictures too,
+// in order to also return whether it holds any artwork
+func OpenLocalTrackArtwork(filename string) (Track, bool, error) {
+	return openLocalTrack(filename, true)
+}
+
+// ArtworkMIME : return input picture real MIME type, if supported as artwork
+func ArtworkMIME(picture []byte) (string, error) {
+	mimeType := http.DetectContentType(picture)
+	if mimeType != ArtworkMIMEJPEG && mimeType != ArtworkMIMEPNG {
+		return "", fmt.Errorf(fmt.Sprintf("Unsupported artwork type: %s", mimeType))
 	}
-	trackMp3, err := id3v2.Open(filename, id3v2.Options{Parse: true})
+	return mimeType, nil
+}
+
+// ArtworkJPEG : return input picture, JPEG encoded
+func ArtworkJPEG(picture []byte) ([]byte, error) {
+	if mimeType, err := ArtworkMIME(picture); err != nil {
+		return nil, err
+	} else if mimeType == ArtworkMIMEJPEG {
+		return picture, nil
+	}
+	source, _, err := image.Decode(bytes.NewReader(picture))
 	if err != nil {
-		return Track{}, fmt.Errorf(fmt.Sprintf("Cannot read id3 tags from \"%s\": %s", filename, err.Error()))
+		return nil, err
 	}
-	track := Track{
-		Title:         TagGetFrame(trackMp3, ID3FrameTitle),
-		Song:          TagGetFrame(trackMp3, ID3FrameSong),
-		Artist:        TagGetFrame(trackMp3, ID3FrameArtist),
-		Album:         TagGetFrame(trackMp3, ID3FrameAlbum),
-		Year:          TagGetFrame(trackMp3, ID3FrameYear),
-		Featurings:    strings.Split(TagGetFrame(trackMp3, ID3FrameFeaturings), "|"),
-		Genre:         TagGetFrame(trackMp3, ID3FrameGenre),
-		TrackNumber:   0,
-		TrackTotals:   0,
-		Duration:      0,
-		SongType:      parseType(TagGetFrame(trackMp3, ID3FrameTitle)),
-		Image:         TagGetFrame(trackMp3, ID3FrameArtworkURL),
-		URL:           TagGetFrame(trackMp3, ID3FrameYouTubeURL),
-		SpotifyID:     TagGetFrame(trackMp3, ID3FrameSpotifyID),
-		Filename:      "",
-		FilenameTemp:  "",
-		FilenameExt:   spttb_system.SongExtension,
-		SearchPattern: "",
-		Lyrics:        TagGetFrame(trackMp3, ID3FrameLyrics),
-		Local:         true,
+	var buffer bytes.Buffer
+	if err := jpeg.Encode(&buffer, source, &jpeg.Options{Quality: ArtworkJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// NewArtworkCache : return a new ArtworkCache keeping artworks into input folder, resized to fit into input size
+// pixels, if greater than zero
+func NewArtworkCache(path string, size int) *ArtworkCache {
+	return &ArtworkCache{Path: path, Size: size}
+}
+
+// Filename : return path input URL artwork gets cached into
+func (cache *ArtworkCache) Filename(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(cache.Path, fmt.Sprintf("%s_%d", hex.EncodeToString(hash[:]), cache.Size))
+}
+
+// Has : return True if input URL artwork has already been cached
+func (cache *ArtworkCache) Has(url string) bool {
+	return len(url) > 0 && spttb_system.FileExists(cache.Filename(url))
+}
+
+// Fetch : return input URL artwork, along with its real MIME type, downloading, resizing and caching it if needed
+func (cache *ArtworkCache) Fetch(url string) ([]byte, string, error) {
+	if len(url) == 0 {
+		return nil, "", fmt.Errorf("No artwork URL")
+	}
+	cache.mutex.Lock()
+	picture, err := ioutil.ReadFile(cache.Filename(url))
+	cache.mutex.Unlock()
+	if err == nil {
+		mimeType, err := ArtworkMIME(picture)
+		return picture, mimeType, err
 	}
 
-	if trackNumber, trackNumberErr := strconv.Atoi(TagGetFrame(trackMp3, ID3FrameTrackNumber)); trackNumberErr == nil {
-		track.TrackNumber = trackNumber
+	artworkClient := http.Client{
+		Timeout: time.Second * spttb_system.HTTPTimeout,
+	}
+	response, err := artworkClient.Get(url)
+	if err != nil {
+		return nil, "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf(fmt.Sprintf("Artwork download from %s returned %s", url, response.Status))
 	}
-	if trackTotals, trackTotalsErr := strconv.Atoi(TagGetFrame(trackMp3, ID3FrameTrackTotals)); trackTotalsErr == nil {
-		track.TrackTotals = trackTotals
+	if picture, err = ioutil.ReadAll(response.Body); err != nil {
+		return nil, "", err
 	}
-	if duration, durationErr := strconv.Atoi(TagGetFrame(trackMp3, ID3FrameDuration)); durationErr == nil {
-		track.Duration = duration
+	if _, err := ArtworkMIME(picture); err != nil {
+		return nil, "", err
+	}
+	if picture, err = resizeArtwork(picture, cache.Size); err != nil {
+		return nil, "", fmt.Errorf(fmt.Sprintf("Unable to resize artwork from %s: %s", url, err.Error()))
 	}
 
-	track.Filename, track.FilenameTemp = parseFilename(track)
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	spttb_system.Mkdir(cache.Path)
+	if err := ioutil.WriteFile(cache.Filename(url)+".part", picture, 0644); err != nil {
+		return nil, "", err
+	}
+	if err := os.Rename(cache.Filename(url)+".part", cache.Filename(url)); err != nil {
+		return nil, "", err
+	}
+	mimeType, err := ArtworkMIME(picture)
+	return picture, mimeType, err
+}
+
+// WriteCover : write input URL artwork, JPEG encoded, into input folder ArtworkCoverFilenames, unless already there,
+// returning how many of them got written
+func (cache *ArtworkCache) WriteCover(url string, folder string) (int, error) {
+	picture, _, err := cache.Fetch(url)
+	if err != nil {
+		return 0, err
+	}
+	if picture, err = ArtworkJPEG(picture); err != nil {
+		return 0, err
+	}
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	var written int
+	for _, coverFilename := range ArtworkCoverFilenames {
+		if coverPath := filepath.Join(folder, coverFilename); !spttb_system.FileExists(coverPath) {
+			if err := ioutil.WriteFile(coverPath, picture, 0644); err != nil {
+				return written, err
+			}
+			written++
+		}
+	}
+	return written, nil
+}
+
+// ParseFix : return Fix out of input fields, made of a local song path or a Spotify track URI, eventually
+// followed by the video URL to redownload it from and by the fix mode, in any order
+func ParseFix(fields []string) (Fix, error) {
+	var (
+		fix          = Fix{Mode: FixModeRedownload}
+		targetFields []string
+	)
+	for _, field := range fields {
+		value := strings.TrimSpace(field)
+		if containsString(FixModes, strings.ToLower(value)) {
+			fix.Mode = strings.ToLower(value)
+		} else if (strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://")) && !spotifyTrackPattern.MatchString(value) {
+			fix.URL = value
+		} else {
+			targetFields = append(targetFields, field)
+		}
+	}
+
+	fix.Target = strings.TrimSpace(strings.Join(targetFields, ","))
+	if len(fix.Target) == 0 {
+		return Fix{}, fmt.Errorf(fmt.Sprintf("No song to fix in \"%s\"", strings.Join(fields, ",")))
+	} else if len(fix.URL) > 0 && fix.Mode != FixModeRedownload {
+		return Fix{}, fmt.Errorf(fmt.Sprintf("A video URL can only be used to %s \"%s\"", FixModeRedownload, fix.Target))
+	}
+	if match := spotifyTrackPattern.FindStringSubmatch(fix.Target); len(match) > 0 {
+		fix.SpotifyID = match[1]
+	} else if path, err := filepath.Abs(fix.Target); err != nil {
+		return Fix{}, err
+	} else if !spttb_system.FileExists(path) {
+		return Fix{}, fmt.Errorf(fmt.Sprintf("%s does not exist", path))
+	} else {
+		fix.Path = path
+	}
+	return fix, nil
+}
+
+// ParseFixFile : return Fix array out of input text or CSV file, one ParseFix fields set per line,
+// ignoring empty and # prefixed ones
+func ParseFixFile(path string) ([]Fix, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		fixes  []Fix
+		reader = csv.NewReader(file)
+	)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf(fmt.Sprintf("Unable to parse %s: %s", path, err.Error()))
+		}
+		if len(strings.TrimSpace(strings.Join(fields, ""))) == 0 {
+			continue
+		}
+		fix, err := ParseFix(fields)
+		if err != nil {
+			line, _ := reader.FieldPos(0)
+			return nil, fmt.Errorf(fmt.Sprintf("Unable to parse %s, line %d: %s", path, line, err.Error()))
+		}
+		fixes = append(fixes, fix)
+	}
+	return fixes, nil
+}
+
+// Snapshot : keep track of the state of the local song at input path, before it gets fixed
+func (fix *Fix) Snapshot(path string) error {
+	tags, err := LoadTags(path, false)
+	if err != nil {
+		return err
+	}
+	defer tags.Close()
+	fix.Path, fix.Frames = path, make(map[int]string)
+	for _, frame := range TagFrames {
+		fix.Frames[frame] = tags.Get(frame)
+	}
+	return nil
+}
+
+// Changes : return human readable changes the song at input path went through, compared to its Fix snapshot
+func (fix Fix) Changes(path string) []string {
+	var changes []string
+	if len(fix.Frames) == 0 {
+		return append(changes, fmt.Sprintf("downloaded into %s", path))
+	}
+	pathBefore, _ := filepath.Abs(fix.Path)
+	pathAfter, _ := filepath.Abs(path)
+	if pathBefore != pathAfter {
+		changes = append(changes, fmt.Sprintf("moved from %s to %s", fix.Path, path))
+	}
+
+	tags, err := LoadTags(path, false)
+	if err != nil {
+		return append(changes, fmt.Sprintf("unreadable (%s)", err.Error()))
+	}
+	defer tags.Close()
+	for _, frame := range TagFrames {
+		before, after := fix.Frames[frame], tags.Get(frame)
+		if before == after {
+			continue
+		} else if frame == ID3FrameYouTubeURL {
+			changes = append(changes, fmt.Sprintf("audio replaced from %s", after))
+		} else if len(before) == 0 {
+			changes = append(changes, fmt.Sprintf("%s added", frameName(frame)))
+		} else if len(after) == 0 {
+			changes = append(changes, fmt.Sprintf("%s removed", frameName(frame)))
+		} else {
+			changes = append(changes, fmt.Sprintf("%s changed", frameName(frame)))
+		}
+	}
+	return changes
+}
+
+// OpenUntaggedTrack : parse whatever tags and filename informations local song has into a new Track object,
+// meant to be matched against Spotify
+func OpenUntaggedTrack(filename string) Track {
+	track := Track{FilenameExt: filepath.Ext(filename)}
+	if trackTags, err := LoadTags(filename, false); err == nil {
+		track.Title = trackTags.Get(ID3FrameTitle)
+		track.Artist = trackTags.Get(ID3FrameArtist)
+		track.Album = trackTags.Get(ID3FrameAlbum)
+		track.ISRC = trackTags.Get(ID3FrameISRC)
+		trackTags.Close()
+	}
+	if artist, title := parseUntaggedFilename(filename); len(track.Title) == 0 {
+		track.Title = title
+		if len(track.Artist) == 0 {
+			track.Artist = artist
+		}
+	} else if len(track.Artist) == 0 {
+		track.Artist = artist
+	}
+	return track
+}
 
-	track.SearchPattern = strings.Replace(track.FilenameTemp[1:], "-", " ", -1)
+// ImportQueries : return Spotify search queries to look for untagged Track with, from the most to the least accurate
+func (track Track) ImportQueries() []string {
+	var queries []string
+	if len(track.ISRC) > 0 {
+		queries = append(queries, fmt.Sprintf("isrc:%s", track.ISRC))
+	}
+	title, _, _ := parseVersion(track.Title)
+	if len(track.Artist) > 0 {
+		queries = append(queries, fmt.Sprintf("track:\"%s\" artist:\"%s\"", title, track.Artist))
+	}
+	return append(queries, Normalize(fmt.Sprintf("%s %s", track.Artist, title)))
+}
+
+// ImportConfidence : return how much (0 to 1) untagged Track seems to be input Spotify track,
+// by ISRC, title, artist and duration
+func (track Track) ImportConfidence(spotifyTrack spotify.FullTrack) float64 {
+	if len(track.ISRC) > 0 && strings.EqualFold(track.ISRC, spotifyTrack.ExternalIDs["isrc"]) {
+		return 1
+	}
+
+	var artistConfidence float64
+	for _, artist := range spotifyTrack.SimpleTrack.Artists {
+		artistConfidence = math.Max(artistConfidence, similarity(track.Artist, artist.Name))
+	}
+	title, _, _ := parseVersion(track.Title)
+	spotifyTitle, _, _ := parseVersion(spotifyTrack.SimpleTrack.Name)
+	titleConfidence := math.Max(similarity(title, spotifyTitle), similarity(track.Title, spotifyTrack.SimpleTrack.Name))
+	if track.Duration == 0 {
+		return titleConfidence*0.6 + artistConfidence*0.4
+	}
 
-	trackMp3.Close()
-	return track, nil
+	durationDelta := math.Abs(float64(track.Duration - spotifyTrack.SimpleTrack.Duration/1000))
+	durationConfidence := math.Max(0, 1-durationDelta/ImportDurationTolerance)
+	return titleConfidence*0.5 + artistConfidence*0.3 + durationConfidence*0.2
+}
+
+// ImportMatch : return, out of input Spotify tracks, the one which untagged Track most likely is, along with its confidence,
+// ignoring the ones without any artist
+func (track Track) ImportMatch(spotifyTracks []spotify.FullTrack) (spotify.FullTrack, float64) {
+	var (
+		match      spotify.FullTrack
+		confidence = -1.0
+	)
+	for _, spotifyTrack := range spotifyTracks {
+		if len(spotifyTrack.SimpleTrack.Artists) == 0 {
+			continue
+		} else if spotifyTrackConfidence := track.ImportConfidence(spotifyTrack); spotifyTrackConfidence > confidence {
+			match, confidence = spotifyTrack, spotifyTrackConfidence
+		}
+	}
+	return match, math.Max(confidence, 0)
 }
 
 // ParseSpotifyTrack : parse Spotify track into a new Track object
@@ -84,6 +375,12 @@ func ParseSpotifyTrack(spotifyTrack spotify.FullTrack, spotifyAlbum spotify.Full
 		Title:  spotifyTrack.SimpleTrack.Name,
 		Artist: (spotifyTrack.SimpleTrack.Artists[0]).Name,
 		Album:  spotifyTrack.Album.Name,
+		AlbumArtist: func() string {
+			if len(spotifyTrack.Album.Artists) > 0 {
+				return spotifyTrack.Album.Artists[0].Name
+			}
+			return ""
+		}(),
 		Year: func() string {
 			if spotifyAlbum.ReleaseDatePrecision == "year" {
 				return spotifyAlbum.ReleaseDate
@@ -109,20 +406,31 @@ func ParseSpotifyTrack(spotifyTrack spotify.FullTrack, spotifyAlbum spotify.Full
 		}(),
 		TrackNumber:   spotifyTrack.SimpleTrack.TrackNumber,
 		TrackTotals:   len(spotifyAlbum.Tracks.Tracks),
+		DiscNumber:    spotifyTrack.SimpleTrack.DiscNumber,
 		Duration:      spotifyTrack.SimpleTrack.Duration / 1000,
-		Image:         spotifyTrack.Album.Images[0].URL,
+		Image:         largestImage(spotifyTrack.Album.Images),
+		Preview:       spotifyTrack.SimpleTrack.PreviewURL,
 		URL:           "",
 		SpotifyID:     spotifyTrack.SimpleTrack.ID.String(),
+		ISRC:          spotifyTrack.ExternalIDs["isrc"],
 		Filename:      "",
 		FilenameTemp:  "",
-		FilenameExt:   spttb_system.SongExtension,
+		FilenameExt:   ActiveExtension,
 		SearchPattern: "",
 		Lyrics:        "",
+		SyncedLyrics:  "",
+		Verification:  "",
+		Quality:       "",
+		TrackGain:     "",
+		TrackPeak:     "",
+		AlbumGain:     "",
+		AlbumPeak:     "",
 		Local:         false,
 	}
 
-	track.SongType = parseType(track.Title)
-	track.Title, track.Song = parseTitle(track.Title, track.Featurings)
+	track.Title, track.VersionTags, track.Versions = parseVersion(track.Title)
+	track.SongType = parseType(track.Versions)
+	track.Title, track.Song = parseTitle(track.Title, track.Featurings, track.VersionTags)
 
 	track.Album = strings.Replace(track.Album, "[", "(", -1)
 	track.Album = strings.Replace(track.Album, "]", ")", -1)
@@ -130,30 +438,170 @@ func ParseSpotifyTrack(spotifyTrack spotify.FullTrack, spotifyAlbum spotify.Full
 	track.Album = strings.Replace(track.Album, "}", ")", -1)
 
 	track.Filename, track.FilenameTemp = parseFilename(track)
+	for _, extension := range spttb_system.SongExtensions {
+		if !spttb_system.FileExists(track.FilenameFinal()) && spttb_system.FileExists(track.Filename+extension) {
+			track.FilenameExt = extension
+		}
+	}
 
-	track.SearchPattern = strings.Replace(track.FilenameTemp[1:], "-", " ", -1)
+	track.SearchPattern = parseSearchPattern(track)
 
 	if spttb_system.FileExists(track.FilenameFinal()) {
 		track.Local = true
 	}
 
 	if track.Local {
-		track.URL = track.GetID3Frame(ID3FrameYouTubeURL)
-		track.Lyrics = track.GetID3Frame(ID3FrameLyrics)
+		if trackTags, trackTagsErr := LoadTags(track.FilenameFinal(), false); trackTagsErr == nil {
+			track.URL = trackTags.Get(ID3FrameYouTubeURL)
+			track.Lyrics = trackTags.Get(ID3FrameLyrics)
+			track.SyncedLyrics = trackTags.Get(ID3FrameSyncedLyrics)
+			track.LyricsTransliteration = trackTags.Get(ID3FrameLyricsTransliteration)
+			track.Verification = trackTags.Get(ID3FrameVerification)
+			track.Quality = trackTags.Get(ID3FrameQuality)
+			track.TrackGain = trackTags.Get(ID3FrameTrackGain)
+			track.TrackPeak = trackTags.Get(ID3FrameTrackPeak)
+			track.AlbumGain = trackTags.Get(ID3FrameAlbumGain)
+			track.AlbumPeak = trackTags.Get(ID3FrameAlbumPeak)
+			trackTags.Close()
+		}
 	}
 
 	return track
 }
 
-// GetTag : open, parse and return filename ID3 tag
+// GetTag : open, parse and return filename input frame value, whatever its tags format
 func GetTag(path string, frame int) string {
-	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	tags, err := LoadTags(path, frame == ID3FrameArtwork)
 	if err != nil {
 		return ""
 	}
-	defer tag.Close()
+	defer tags.Close()
+
+	if frame == ID3FrameArtwork {
+		return tags.tagger.GetFrame(frame)
+	}
+	return tags.Get(frame)
+}
+
+// LoadTags : open and parse input song tags at once, eventually skipping pictures bodies, which
+// makes them cheaper to load but leaves them unknown and makes Tags read-only
+func LoadTags(path string, pictures bool) (*Tags, error) {
+	tagger, err := openTag(path, pictures)
+	if err != nil {
+		return nil, err
+	}
+	tags := &Tags{
+		Path:    path,
+		Frames:  make(map[int]string),
+		Partial: !pictures && tagFormat(path) == TagFormatID3,
+		tagger:  tagger,
+		changed: make(map[int]bool),
+	}
+	for _, frame := range TagFrames {
+		if value := tagger.GetFrame(frame); len(value) > 0 {
+			tags.Frames[frame] = value
+		}
+	}
+	tags.Artwork = !tags.Partial && tagger.HasFrame(ID3FrameArtwork)
+	if id3Tagger, ok := tagger.(*id3Tagger); ok {
+		tags.Legacy = TagLegacyFrames(id3Tagger.tag)
+	}
+	return tags, nil
+}
+
+// TagFrameDescription : return input custom frame description, as it gets stored with
+func TagFrameDescription(frame int) string {
+	return tagFrameDescriptions[frame]
+}
+
+// Get : return Tags input frame value
+func (tags *Tags) Get(frame int) string {
+	if frame == ID3FrameArtwork {
+		return string(tags.picture)
+	}
+	return tags.Frames[frame]
+}
+
+// Has : return True if Tags have valued input frame
+func (tags *Tags) Has(frame int) bool {
+	if frame == ID3FrameArtwork {
+		return tags.Artwork
+	}
+	return len(tags.Frames[frame]) > 0
+}
+
+// Set : set Tags input frame value, to be written on Save
+func (tags *Tags) Set(frame int, value string) {
+	tags.Frames[frame] = value
+	tags.changed[frame] = true
+}
+
+// SetArtwork : set Tags front cover picture, to be written on Save
+func (tags *Tags) SetArtwork(mimeType string, picture []byte) {
+	tags.picture, tags.pictureMime, tags.Artwork = picture, mimeType, true
+}
+
+// Clear : drop every Tags frame, to be written on Save
+func (tags *Tags) Clear() {
+	tags.Frames = make(map[int]string)
+	tags.changed = make(map[int]bool)
+	tags.cleared = true
+	tags.picture, tags.pictureMime, tags.Artwork = nil, "", false
+}
+
+// Save : write every changed Tags frame into their song at once
+func (tags *Tags) Save() error {
+	if tags.Partial {
+		return fmt.Errorf(fmt.Sprintf("Tags of \"%s\" have been loaded without pictures and cannot be written", tags.Path))
+	}
+	if tags.cleared {
+		tags.tagger.DeleteFrames()
+	}
+	for _, frame := range TagFrames {
+		if tags.changed[frame] {
+			tags.tagger.SetFrame(frame, tags.Frames[frame])
+		}
+	}
+	if len(tags.picture) > 0 {
+		tags.tagger.SetArtwork(tags.pictureMime, tags.picture)
+	}
+	if err := tags.tagger.Save(); err != nil {
+		return err
+	}
+	tags.changed, tags.cleared, tags.Legacy = make(map[int]bool), false, nil
+	return nil
+}
+
+// Close : release Tags song file
+func (tags *Tags) Close() error {
+	return tags.tagger.Close()
+}
+
+// OpenTag : open and parse input song tags, picking the right Tagger for its format
+func OpenTag(path string) (Tagger, error) {
+	return openTag(path, true)
+}
 
-	return TagGetFrame(tag, frame)
+func openTag(path string, pictures bool) (Tagger, error) {
+	switch format := tagFormat(path); format {
+	case TagFormatID3:
+		options := id3v2.Options{Parse: true}
+		if !pictures {
+			options.ParseFrames = tagID3ParseFrames
+		}
+		tag, err := id3v2.Open(path, options)
+		if err != nil {
+			return nil, err
+		}
+		return &id3Tagger{tag: tag}, nil
+	case TagFormatVorbis, TagFormatMP4:
+		tagger, err := openFFmpegTagger(path, format)
+		if err != nil {
+			return nil, err
+		}
+		return tagger, nil
+	}
+	return nil, fmt.Errorf(fmt.Sprintf("Unsupported tags format for \"%s\"", path))
 }
 
 // FlushLocal : recheck - and eventually update it - if track is local
@@ -164,31 +612,334 @@ func (track Track) FlushLocal() Track {
 	return track
 }
 
+// ValidatePathTemplate : return nil error if input path template is relative, made of non-empty
+// components and only refers to known fields
+func ValidatePathTemplate(template string) error {
+	if len(strings.TrimSpace(template)) == 0 {
+		return fmt.Errorf("Path template cannot be empty")
+	}
+	for _, component := range strings.Split(template, "/") {
+		if len(strings.TrimSpace(component)) == 0 || strings.TrimSpace(component) == ".." {
+			return fmt.Errorf(fmt.Sprintf("Path template \"%s\" must be relative, with non-empty components", template))
+		}
+		for _, match := range pathTemplatePattern.FindAllStringSubmatch(component, -1) {
+			if !containsString(PathTemplateFields, match[1]) {
+				return fmt.Errorf(fmt.Sprintf("Unknown path template field: %s", match[1]))
+			}
+		}
+	}
+	return nil
+}
+
+// NewIndex : return a new, empty, Index
+func NewIndex() *Index {
+	return &Index{
+		Version:   IndexVersion,
+		Records:   make(map[string]IndexRecord),
+		Untracked: make(map[string]IndexRecord),
+	}
+}
+
+// LoadIndex : load Index from input gob file, converting it if in legacy format and dropping it
+// if in a different version one, as songs will be scanned again anyway
+func LoadIndex(path string) (*Index, error) {
+	index := NewIndex()
+	if err := spttb_system.FetchGob(path, index); err == nil {
+		if index.Version != IndexVersion {
+			return NewIndex(), nil
+		}
+		if index.Records == nil {
+			index.Records = make(map[string]IndexRecord)
+		}
+		if index.Untracked == nil {
+			index.Untracked = make(map[string]IndexRecord)
+		}
+		return index, nil
+	}
+
+	legacyIndex := TracksIndex{}
+	if err := spttb_system.FetchGob(path, &legacyIndex); err != nil {
+		return NewIndex(), fmt.Errorf(fmt.Sprintf("Unable to decode index: %s", err.Error()))
+	}
+	index = NewIndex()
+	for spotifyID, trackPath := range legacyIndex {
+		index.Records[spotifyID] = IndexRecord{Path: trackPath}
+	}
+	return index, nil
+}
+
+// Dump : write Index into input gob file
+func (index *Index) Dump(path string) error {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return spttb_system.DumpGob(path, index)
+}
+
+// Len : return Index records count
+func (index *Index) Len() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return len(index.Records)
+}
+
+// Get : return Index record for input Spotify ID, if any
+func (index *Index) Get(spotifyID string) (IndexRecord, bool) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	record, ok := index.Records[spotifyID]
+	return record, ok
+}
+
+// Path : return indexed song path for input Spotify ID, if any
+func (index *Index) Path(spotifyID string) (string, bool) {
+	record, ok := index.Get(spotifyID)
+	return record.Path, ok
+}
+
+// Relocate : update Index record for input Spotify ID, as its song got moved to input path
+func (index *Index) Relocate(spotifyID string, path string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	record := index.Records[spotifyID]
+	record.Path = path
+	index.Records[spotifyID] = record
+}
+
+// Touch : update Index record modification time for input Spotify ID, as long as it was up to date with previous one,
+// so that songs whose timestamps just got flushed do not need to be read again
+func (index *Index) Touch(spotifyID string, previous time.Time, modTime time.Time) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	if record, ok := index.Records[spotifyID]; ok && record.ModTime.Equal(previous) {
+		record.ModTime = modTime
+		index.Records[spotifyID] = record
+	}
+}
+
+// Fingerprint : keep input audio fingerprint of the song at input path, downloaded from input URL,
+// on Index record for input Spotify ID
+func (index *Index) Fingerprint(spotifyID string, path string, url string, fingerprint []uint32) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+	record := index.Records[spotifyID]
+	record.Path = path
+	record.VideoID = parseVideoID(url)
+	record.Fingerprint = fingerprint
+	index.Records[spotifyID] = record
+}
+
+// Scan : align Index to input songs paths, reading just the new or modified ones, through a pool of
+// input workers count, and dropping records of vanished ones, returning how many songs got read
+func (index *Index) Scan(paths []string, workers int) int {
+	var (
+		pathsPresent = make(map[string]bool)
+		pathsRecords = make(map[string]IndexRecord)
+		pathsIDs     = make(map[string]string)
+		pathsQueue   = make(chan string)
+		waitGroup    sync.WaitGroup
+		scanned      int
+	)
+	for _, path := range paths {
+		pathsPresent[path] = true
+	}
+
+	index.mutex.Lock()
+	for spotifyID, record := range index.Records {
+		if !pathsPresent[record.Path] {
+			delete(index.Records, spotifyID)
+			continue
+		}
+		pathsRecords[record.Path], pathsIDs[record.Path] = record, spotifyID
+	}
+	for path, record := range index.Untracked {
+		if !pathsPresent[path] {
+			delete(index.Untracked, path)
+			continue
+		}
+		pathsRecords[path] = record
+	}
+	index.mutex.Unlock()
+
+	for worker := 0; worker < workers; worker++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for path := range pathsQueue {
+				info, err := os.Stat(path)
+				if err != nil {
+					continue
+				}
+				spotifyID, record, err := readIndexRecord(path, info)
+				if err != nil {
+					continue
+				}
+				index.mutex.Lock()
+				if previousID, ok := pathsIDs[path]; ok && previousID != spotifyID {
+					delete(index.Records, previousID)
+				}
+				if len(spotifyID) > 0 {
+					if previous, ok := index.Records[spotifyID]; ok && previous.VideoID == record.VideoID {
+						record.Fingerprint = previous.Fingerprint
+					}
+					index.Records[spotifyID] = record
+					delete(index.Untracked, path)
+				} else {
+					index.Untracked[path] = record
+				}
+				scanned++
+				index.mutex.Unlock()
+			}
+		}()
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if record, ok := pathsRecords[path]; ok && record.Size == info.Size() && record.ModTime.Equal(info.ModTime()) {
+			continue
+		}
+		pathsQueue <- path
+	}
+	close(pathsQueue)
+	waitGroup.Wait()
+	return scanned
+}
+
+// ParseAuditFixes : return audit categories matching input names, checking them to be fixable
+func ParseAuditFixes(names []string) ([]string, error) {
+	var categories []string
+	for _, name := range names {
+		category := strings.ToLower(strings.TrimSpace(name))
+		if len(category) == 0 {
+			continue
+		} else if category == "all" {
+			return AuditFixableCategories, nil
+		} else if !containsString(AuditCategories, category) {
+			return nil, fmt.Errorf(fmt.Sprintf("Unknown audit category: %s", name))
+		} else if !containsString(AuditFixableCategories, category) {
+			return nil, fmt.Errorf(fmt.Sprintf("Audit category cannot be automatically fixed: %s", name))
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
+
+// NewAuditReport : return a new empty AuditReport about input folder
+func NewAuditReport(folder string) *AuditReport {
+	report := &AuditReport{
+		Folder:  folder,
+		Time:    time.Now(),
+		Summary: make(map[string]int),
+		Issues:  []AuditIssue{},
+	}
+	for _, category := range AuditCategories {
+		report.Summary[category] = 0
+	}
+	return report
+}
+
+// Append : add an issue of input category about input path to AuditReport, returning its position
+func (report *AuditReport) Append(category string, path string, detail string) int {
+	report.Issues = append(report.Issues, AuditIssue{Category: category, Path: path, Detail: detail})
+	report.Summary[category]++
+	return len(report.Issues) - 1
+}
+
+// AppendDuplicates : add an issue of input category for every path sharing its value with other ones,
+// out of input value - paths mapping
+func (report *AuditReport) AppendDuplicates(category string, values map[string][]string) {
+	var keys []string
+	for value, paths := range values {
+		if len(value) > 0 && len(paths) > 1 {
+			keys = append(keys, value)
+		}
+	}
+	slice.Sort(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	for _, value := range keys {
+		paths := values[value]
+		slice.Sort(paths, func(i, j int) bool {
+			return paths[i] < paths[j]
+		})
+		for _, path := range paths {
+			var others []string
+			for _, other := range paths {
+				if other != path {
+					others = append(others, other)
+				}
+			}
+			report.Append(category, path, fmt.Sprintf("%s shared with %s", value, strings.Join(others, ", ")))
+		}
+	}
+}
+
+// CategoryIssues : return AuditReport issues of input category
+func (report *AuditReport) CategoryIssues(category string) []AuditIssue {
+	var issues []AuditIssue
+	for _, issue := range report.Issues {
+		if issue.Category == category {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// CountFixed : return how many AuditReport issues of input category got fixed
+func (report *AuditReport) CountFixed(category string) int {
+	var fixed int
+	for _, issue := range report.CategoryIssues(category) {
+		if issue.Fixed {
+			fixed++
+		}
+	}
+	return fixed
+}
+
+// JSON : return AuditReport, JSON encoded
+func (report *AuditReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(report, "", "  ")
+}
+
+// Dump : write AuditReport, JSON encoded, into input path
+func (report *AuditReport) Dump(path string) error {
+	reportJSON, err := report.JSON()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, reportJSON, 0644)
+}
+
 // FilenameFinal : return Track final filename
 func (track Track) FilenameFinal() string {
 	return track.Filename + track.FilenameExt
 }
 
-// FilenameTemporary : return Track temporary filename
+// FilenamePlaylist : return Track final filename flattened into a single path component, unique across folders,
+// as linked into playlists folders
+func (track Track) FilenamePlaylist() string {
+	return strings.Replace(track.FilenameFinal(), "/", " - ", -1)
+}
+
+// FilenameTemporary : return Track temporary filename, inside active workspace
 func (track Track) FilenameTemporary() string {
-	return track.FilenameTemp + track.FilenameExt
+	return filepath.Join(ActiveWorkspace, track.FilenameTemp+track.FilenameExt)
 }
 
-// FilenameArtwork : return Track artwork filename
-func (track Track) FilenameArtwork() string {
-	return "." + strings.Split(track.Image, "/")[len(strings.Split(track.Image, "/"))-1] + ".jpg"
+// FilenameLyrics : return Track synced lyrics sidecar filename
+func (track Track) FilenameLyrics() string {
+	return track.Filename + LyricsSidecarExtension
 }
 
-// TempFiles : return strings array containing all possible junk file names
-func (track Track) TempFiles() []string {
-	var tempFiles []string
-	for _, fnamePrefix := range []string{track.FilenameTemporary(), track.FilenameTemp, track.FilenameArtwork()} {
-		tempFiles = append(tempFiles, fnamePrefix)
-		for _, fnameJunkSuffix := range JunkSuffixes {
-			tempFiles = append(tempFiles, fnamePrefix+fnameJunkSuffix)
-		}
-	}
-	return tempFiles
+// FilenameStream : return Track native audio stream filename, inside active workspace, as downloaded before getting encoded
+func (track Track) FilenameStream() string {
+	return filepath.Join(ActiveWorkspace, track.FilenameTemp+".stream")
+}
+
+// FilenameStreamOrigin : return Track native audio stream origin filename, holding the URL it got downloaded from
+func (track Track) FilenameStreamOrigin() string {
+	return track.FilenameStream() + ".origin"
 }
 
 // Seems : return nil error if sequence is input sequence string matches with Track
@@ -199,9 +950,23 @@ func (track Track) Seems(sequence string) error {
 	if strings.Contains(strings.ToLower(sequence), "full album") {
 		return fmt.Errorf("Item seems to be pointing to an album, not to a song")
 	}
-	for _, songType := range SongTypes {
-		if SeemsType(sequence, songType) && track.SongType != songType {
-			return fmt.Errorf("Songs seem to be of different types")
+	return track.SeemsVersion(sequence)
+}
+
+// SeemsVersion : return nil error if input sequence versions are compatible with Track ones, according to version rules
+func (track Track) SeemsVersion(sequence string) error {
+	sequenceVersions := matchVersions(sequence, append([]string{track.Song, track.Artist}, track.Featurings...)...)
+	for _, rule := range ActiveVersionRules {
+		var (
+			trackHas    = containsString(track.Versions, rule.Name)
+			sequenceHas = containsString(sequenceVersions, rule.Name)
+		)
+		switch {
+		case rule.Policy == VersionPolicyStrict && trackHas != sequenceHas,
+			rule.Policy == VersionPolicyRequire && trackHas && !sequenceHas:
+			return fmt.Errorf("Songs seem to be of different versions: \"%s\" expected %t, found %t", rule.Name, trackHas, sequenceHas)
+		case rule.Policy == VersionPolicyReject && !trackHas && sequenceHas:
+			return fmt.Errorf("Songs seem to be of different versions: \"%s\" not expected", rule.Name)
 		}
 	}
 	return nil
@@ -209,7 +974,6 @@ func (track Track) Seems(sequence string) error {
 
 // SeemsByWordMatch : return nil error if Track song name, artist and featurings are contained in sequence
 func (track Track) SeemsByWordMatch(sequence string) error {
-	sequence = sanitize.Name(strings.ToLower(sequence))
 	for _, trackItem := range append([]string{track.Song, track.Artist}, track.Featurings...) {
 		trackItem = strings.ToLower(trackItem)
 		if len(trackItem) > 7 && trackItem[:7] == "cast of" {
@@ -221,23 +985,43 @@ func (track Track) SeemsByWordMatch(sequence string) error {
 		if strings.Contains(trackItem, " and ") {
 			trackItem = strings.Split(trackItem, " and ")[0]
 		}
-		trackItem = strings.TrimSpace(trackItem)
-		trackItem = sanitize.Name(trackItem)
-		if len(trackItem) > 3 && !strings.Contains(sequence, trackItem) {
+		trackItem = Normalize(trackItem)
+		if isSignificant(trackItem) && !SeemsContained(sequence, trackItem) {
 			return fmt.Errorf("Songs seem to be mismatching by words comparison: \"%v+\" in \"%s\", due to \"%s\"",
-				append([]string{track.Song, track.Artist}, track.Featurings...), sequence, trackItem)
+				append([]string{track.Song, track.Artist}, track.Featurings...), Normalize(sequence), trackItem)
 		}
 	}
 	return nil
 }
 
-// JunkWildcards : return strings array containing all possible junk filenames wilcards
-func JunkWildcards() []string {
-	var junkWildcards []string
-	for _, junkSuffix := range JunkSuffixes {
-		junkWildcards = append(junkWildcards, ".*"+junkSuffix)
+// SeemsContained : return True if input item is contained in input sequence, comparing them
+// both in their native script and in their Latin transliteration
+func SeemsContained(sequence string, item string) bool {
+	if strings.Contains(Normalize(sequence), Normalize(item)) {
+		return true
 	}
-	return append(junkWildcards, ".*.mp3")
+	return strings.Contains(Transliterate(sequence), Transliterate(item))
+}
+
+// Normalize : return NFKC folded, lowercased version of input sequence, keeping letters and numbers
+// of any script, dropping symbols and collapsing separators into single spaces
+func Normalize(sequence string) string {
+	sequence = strings.ToLower(norm.NFKC.String(sequence))
+	sequence = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r) {
+			return r
+		}
+		if unicode.IsSpace(r) || strings.ContainsRune(NormalizeSeparators, r) {
+			return ' '
+		}
+		return -1
+	}, sequence)
+	return strings.Join(strings.Fields(sequence), " ")
+}
+
+// Transliterate : return normalized Latin transliteration of input sequence
+func Transliterate(sequence string) string {
+	return Normalize(unidecode.Unidecode(Normalize(sequence)))
 }
 
 // TagGetFrame : get input frame from open input Tag
@@ -267,66 +1051,65 @@ func TagGetFrame(tag *id3v2.Tag, frame int) string {
 		return TagGetFrameArtworkURL(tag)
 	case ID3FrameLyrics:
 		return TagGetFrameLyrics(tag)
+	case ID3FrameSyncedLyrics:
+		return TagGetFrameSyncedLyrics(tag)
+	case ID3FrameLyricsTransliteration:
+		return TagGetFrameLyricsTransliteration(tag)
 	case ID3FrameYouTubeURL:
 		return TagGetFrameYouTubeURL(tag)
 	case ID3FrameDuration:
 		return TagGetFrameDuration(tag)
 	case ID3FrameSpotifyID:
 		return TagGetFrameSpotifyID(tag)
+	case ID3FrameVerification:
+		return TagGetFrameVerification(tag)
+	case ID3FrameQuality:
+		return TagGetFrameQuality(tag)
+	case ID3FrameAlbumArtist:
+		return TagGetFrameAlbumArtist(tag)
+	case ID3FrameDiscNumber:
+		return TagGetFrameDiscNumber(tag)
+	case ID3FrameISRC:
+		return TagGetFrameISRC(tag)
+	case ID3FrameTrackGain:
+		return TagGetFrameReplayGain(tag, ReplayGainTrackGain)
+	case ID3FrameTrackPeak:
+		return TagGetFrameReplayGain(tag, ReplayGainTrackPeak)
+	case ID3FrameAlbumGain:
+		return TagGetFrameReplayGain(tag, ReplayGainAlbumGain)
+	case ID3FrameAlbumPeak:
+		return TagGetFrameReplayGain(tag, ReplayGainAlbumPeak)
 	}
 	return ""
 }
 
 // TagGetFrameSong : get track song title frame from input Tag
 func TagGetFrameSong(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "song" {
-				return comment.Text
-			}
-		}
+	if value := tagGetUserText(tag, "song"); len(value) > 0 {
+		return value
 	}
-	return ""
+	return tagGetComment(tag, "song")
 }
 
 // TagGetFrameFeaturings : get track featurings frame from input Tag
 func TagGetFrameFeaturings(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "featurings" {
-				return comment.Text
-			}
-		}
+	if value := tagGetUserText(tag, "featurings"); len(value) > 0 {
+		return value
 	}
-	return ""
+	return tagGetComment(tag, "featurings")
 }
 
 // TagGetFrameTrackNumber : get track number frame from input Tag
 func TagGetFrameTrackNumber(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Track number/Position in set"))) > 0 {
-		for _, frameText := range tag.GetFrames(tag.CommonID("Track number/Position in set")) {
-			text, ok := frameText.(id3v2.TextFrame)
-			if ok {
-				return text.Text
-			}
-		}
-	}
-	return ""
+	return strings.Split(tagGetText(tag, "TRCK"), "/")[0]
 }
 
 // TagGetFrameTrackTotals : get total tracks number frame from input Tag
 func TagGetFrameTrackTotals(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "trackTotals" {
-				return comment.Text
-			}
-		}
+	if trackNumber := strings.SplitN(tagGetText(tag, "TRCK"), "/", 2); len(trackNumber) == 2 && len(trackNumber[1]) > 0 {
+		return trackNumber[1]
 	}
-	return ""
+	return tagGetComment(tag, "trackTotals")
 }
 
 // TagGetFrameArtwork : get artwork frame from input Tag
@@ -344,141 +1127,823 @@ func TagGetFrameArtwork(tag *id3v2.Tag) string {
 
 // TagGetFrameArtworkURL : get artwork URL frame from input Tag
 func TagGetFrameArtworkURL(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "artwork" {
-				return comment.Text
-			}
-		}
+	if value := tagGetUserURL(tag, "artwork"); len(value) > 0 {
+		return value
 	}
-	return ""
+	return tagGetComment(tag, "artwork")
 }
 
 // TagGetFrameLyrics : get lyrics frame from input Tag
 func TagGetFrameLyrics(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))) > 0 {
-		for _, frameLyrics := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
-			lyrics, ok := frameLyrics.(id3v2.UnsynchronisedLyricsFrame)
-			if ok {
-				return lyrics.Lyrics
-			}
+	for _, frameLyrics := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		lyrics, ok := frameLyrics.(id3v2.UnsynchronisedLyricsFrame)
+		if ok && lyrics.ContentDescriptor != LyricsTransliterationDescriptor {
+			return lyrics.Lyrics
 		}
 	}
 	return ""
 }
 
-// TagGetFrameYouTubeURL : get youtube URL frame from input Tag
-func TagGetFrameYouTubeURL(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "youtube" {
-				return comment.Text
-			}
+// TagGetFrameLyricsTransliteration : get transliterated lyrics frame from input Tag
+func TagGetFrameLyricsTransliteration(tag *id3v2.Tag) string {
+	for _, frameLyrics := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		lyrics, ok := frameLyrics.(id3v2.UnsynchronisedLyricsFrame)
+		if ok && lyrics.ContentDescriptor == LyricsTransliterationDescriptor {
+			return lyrics.Lyrics
 		}
 	}
 	return ""
 }
 
-// TagGetFrameDuration : get duration frame from input Tag
-func TagGetFrameDuration(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "duration" {
-				return comment.Text
-			}
+// TagGetFrameSyncedLyrics : get synced lyrics frame from input Tag, as LRC text
+func TagGetFrameSyncedLyrics(tag *id3v2.Tag) string {
+	for _, frameLyrics := range tag.GetFrames("SYLT") {
+		if lyrics, ok := parseSyncedLyricsFrame(frameLyrics); ok && len(lyrics.Lines) > 0 {
+			return lyrics.Lines.String()
 		}
 	}
 	return ""
 }
 
+// TagGetFrameYouTubeURL : get youtube URL frame from input Tag
+func TagGetFrameYouTubeURL(tag *id3v2.Tag) string {
+	if value := tagGetURL(tag, "WOAS"); len(value) > 0 {
+		return value
+	}
+	return tagGetComment(tag, "youtube")
+}
+
+// TagGetFrameDuration : get duration frame from input Tag
+func TagGetFrameDuration(tag *id3v2.Tag) string {
+	if length, lengthErr := strconv.Atoi(tagGetText(tag, "TLEN")); lengthErr == nil {
+		return strconv.Itoa(length / 1000)
+	}
+	return tagGetComment(tag, "duration")
+}
+
 // TagGetFrameSpotifyID : get Spotify ID frame from input Tag
 func TagGetFrameSpotifyID(tag *id3v2.Tag) string {
-	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
-		for _, frameComment := range tag.GetFrames(tag.CommonID("Comments")) {
-			comment, ok := frameComment.(id3v2.CommentFrame)
-			if ok && comment.Description == "spotifyid" {
-				return comment.Text
-			}
+	if value := tagGetUFID(tag, TagUFIDOwner); len(value) > 0 {
+		return value
+	}
+	return tagGetComment(tag, "spotifyid")
+}
+
+// TagGetFrameVerification : get download verification frame from input Tag
+func TagGetFrameVerification(tag *id3v2.Tag) string {
+	if value := tagGetUserText(tag, "verification"); len(value) > 0 {
+		return value
+	}
+	return tagGetComment(tag, "verification")
+}
+
+// TagGetFrameAlbumArtist : get album artist frame from input Tag
+func TagGetFrameAlbumArtist(tag *id3v2.Tag) string {
+	return tagGetText(tag, "TPE2")
+}
+
+// TagGetFrameDiscNumber : get disc number frame from input Tag
+func TagGetFrameDiscNumber(tag *id3v2.Tag) string {
+	return strings.Split(tagGetText(tag, "TPOS"), "/")[0]
+}
+
+// TagGetFrameISRC : get ISRC frame from input Tag
+func TagGetFrameISRC(tag *id3v2.Tag) string {
+	return tagGetText(tag, "TSRC")
+}
+
+// TagLegacyFrames : return frames of input Tag still stored as legacy comments, instead of standard frames
+func TagLegacyFrames(tag *id3v2.Tag) []int {
+	var frames []int
+	for _, frame := range TagFrames {
+		if description, ok := tagFrameDescriptions[frame]; ok && len(tagGetComment(tag, description)) > 0 {
+			frames = append(frames, frame)
 		}
 	}
-	return ""
+	return frames
+}
+
+// TagGetFrameQuality : get estimated audio quality frame from input Tag
+func TagGetFrameQuality(tag *id3v2.Tag) string {
+	if value := tagGetUserText(tag, "quality"); len(value) > 0 {
+		return value
+	}
+	return tagGetComment(tag, "quality")
 }
 
 // TagHasFrame : return True if open input Tag has valued input frame
 func TagHasFrame(tag *id3v2.Tag, frame int) bool {
+	if frame == ID3FrameArtwork {
+		return len(tag.GetFrames(tag.CommonID("Attached picture"))) > 0
+	}
 	return TagGetFrame(tag, frame) != ""
 }
 
-// GetID3Frame : get Track ID3 input frame string value
+// GetID3Frame : get Track input frame string value, whatever its tags format
 func (track Track) GetID3Frame(frame int) string {
-	tag, err := id3v2.Open(track.FilenameFinal(), id3v2.Options{Parse: true})
-	if tag == nil || err != nil {
-		return ""
-	}
-	defer tag.Close()
-	return TagGetFrame(tag, frame)
+	return GetTag(track.FilenameFinal(), frame)
 }
 
-// HasID3Frame : return True if Track has input ID3 frame
+// HasID3Frame : return True if Track has input frame, whatever its tags format
 func (track *Track) HasID3Frame(frame int) bool {
-	return track.GetID3Frame(frame) != ""
+	tags, err := LoadTags(track.FilenameFinal(), frame == ID3FrameArtwork)
+	if err != nil {
+		return false
+	}
+	defer tags.Close()
+	return tags.Has(frame)
 }
 
-// SearchLyrics : search Track lyrics, eventually throwing returning error
-func (track *Track) SearchLyrics() error {
+// SearchLyrics : search Track lyrics through active lyrics providers, in order, consulting and
+// updating input cache, if any, eventually throwing returning error
+func (track *Track) SearchLyrics(cache LyricsCache) error {
 	var (
-		lyrics    string
-		lyricsErr error
+		providers      = ActiveLyricsProviders.String()
+		lyricsErr      error
+		lyricsAnswered bool
 	)
-	lyrics, lyricsErr = searchLyricsGenius(track)
-	if lyricsErr == nil {
-		track.Lyrics = lyrics
-		return nil
+	if entry, ok := cache[track.SpotifyID]; ok && len(track.SpotifyID) > 0 {
+		if len(entry.Lyrics) > 0 && entry.Version == LyricsCacheVersion {
+			track.Lyrics = entry.Lyrics
+			return nil
+		} else if len(entry.Lyrics) == 0 && entry.Providers == providers && time.Since(entry.Time) < LyricsNegativeCacheTTL*time.Hour {
+			return fmt.Errorf(fmt.Sprintf("Lyrics not found by %s, as cached %s", providers, entry.Time.Format("2006-01-02")))
+		}
 	}
-	lyrics, lyricsErr = searchLyricsOvh(track)
-	if lyricsErr == nil {
+
+	for _, provider := range ActiveLyricsProviders {
+		if !provider.Available() {
+			continue
+		}
+		lyrics, err := provider.Search(track)
+		if err != nil {
+			lyricsErr = fmt.Errorf(fmt.Sprintf("%s: %s", provider.Name(), err.Error()))
+			continue
+		}
+		lyricsAnswered = true
+		if len(lyrics) > 0 {
+			track.Lyrics = lyrics
+			if cache != nil && len(track.SpotifyID) > 0 {
+				cache[track.SpotifyID] = LyricsCacheEntry{Lyrics: lyrics, Provider: provider.Name(), Providers: providers, Time: time.Now(), Version: LyricsCacheVersion}
+			}
+			return nil
+		}
+	}
+	if !lyricsAnswered && lyricsErr != nil {
+		return lyricsErr
+	}
+	if cache != nil && len(track.SpotifyID) > 0 {
+		cache[track.SpotifyID] = LyricsCacheEntry{Providers: providers, Time: time.Now(), Version: LyricsCacheVersion}
+	}
+	return fmt.Errorf(fmt.Sprintf("Lyrics not found by %s", providers))
+}
+
+// ParseLyricsProviders : return lyrics providers matching input names, in the same order
+func ParseLyricsProviders(names []string) (LyricsProviders, error) {
+	var providers LyricsProviders
+	for _, name := range names {
+		var found bool
+		for _, provider := range DefaultLyricsProviders {
+			if provider.Name() == strings.ToLower(strings.TrimSpace(name)) {
+				providers, found = append(providers, provider), true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf(fmt.Sprintf("Unknown lyrics provider: %s", name))
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("No lyrics provider selected")
+	}
+	return providers, nil
+}
+
+// String : return lyrics providers names, comma separated
+func (providers LyricsProviders) String() string {
+	var names []string
+	for _, provider := range providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Name : return Genius lyrics provider name
+func (provider geniusLyricsProvider) Name() string {
+	return LyricsProviderGenius
+}
+
+// Available : return True if Genius lyrics provider can be used, i.e. a valid token has been provided
+func (provider geniusLyricsProvider) Available() bool {
+	return len(geniusToken()) > 0
+}
+
+// Search : search Track lyrics on Genius
+func (provider geniusLyricsProvider) Search(track *Track) (string, error) {
+	return searchLyricsGenius(track)
+}
+
+// Name : return lyrics.ovh lyrics provider name
+func (provider ovhLyricsProvider) Name() string {
+	return LyricsProviderOVH
+}
+
+// Available : return True if lyrics.ovh lyrics provider can be used
+func (provider ovhLyricsProvider) Available() bool {
+	return true
+}
+
+// Search : search Track lyrics on lyrics.ovh
+func (provider ovhLyricsProvider) Search(track *Track) (string, error) {
+	return searchLyricsOvh(track)
+}
+
+// Name : return LRCLIB lyrics provider name
+func (provider lrclibLyricsProvider) Name() string {
+	return LyricsProviderLRCLib
+}
+
+// Available : return True if LRCLIB lyrics provider can be used
+func (provider lrclibLyricsProvider) Available() bool {
+	return true
+}
+
+// Search : search Track plain lyrics on LRCLIB
+func (provider lrclibLyricsProvider) Search(track *Track) (string, error) {
+	_, lyrics, err := searchLyricsLRCLib(track)
+	return lyrics, err
+}
+
+// SearchSyncedLyrics : search Track time-synced lyrics, validating them against Track duration,
+// eventually throwing returning error
+func (track *Track) SearchSyncedLyrics() error {
+	syncedLyrics, lyrics, lyricsErr := searchLyricsLRCLib(track)
+	if lyricsErr != nil {
+		return lyricsErr
+	}
+	if len(track.Lyrics) == 0 && len(lyrics) > 0 {
 		track.Lyrics = lyrics
-		return nil
 	}
-	return lyricsErr
+	if len(syncedLyrics) == 0 {
+		return fmt.Errorf("LRCLIB synced lyrics not found")
+	}
+	lines, linesErr := ParseLRC(syncedLyrics)
+	if linesErr != nil {
+		return fmt.Errorf(fmt.Sprintf("Unable to parse synced lyrics: %s", linesErr.Error()))
+	}
+	if err := lines.Validate(track.Duration); err != nil {
+		return fmt.Errorf(fmt.Sprintf("Synced lyrics do not fit the song: %s", err.Error()))
+	}
+	track.SyncedLyrics = lines.String()
+	return nil
+}
+
+// ParseLRC : parse input LRC text into synced lyrics lines, sorted by timestamp, applying its offset, if any
+func ParseLRC(text string) (LyricsLines, error) {
+	var (
+		lines  LyricsLines
+		offset int
+	)
+	for lineIndex, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if tag := lyricsTagPattern.FindStringSubmatch(line); len(tag) > 0 {
+			if strings.ToLower(tag[1]) == "offset" {
+				offset, _ = strconv.Atoi(strings.TrimSpace(tag[2]))
+			}
+			continue
+		}
+
+		var timestamps []int
+		for {
+			timestamp := lyricsTimestampPattern.FindStringSubmatch(line)
+			if len(timestamp) == 0 {
+				break
+			}
+			value, err := parseLyricsTimestamp(timestamp[1], timestamp[2], timestamp[3])
+			if err != nil {
+				return nil, fmt.Errorf(fmt.Sprintf("Line %d: %s", lineIndex+1, err.Error()))
+			}
+			timestamps = append(timestamps, value)
+			line = line[len(timestamp[0]):]
+		}
+		if len(timestamps) == 0 {
+			return nil, fmt.Errorf(fmt.Sprintf("Line %d has no timestamp", lineIndex+1))
+		}
+		for _, timestamp := range timestamps {
+			if timestamp -= offset; timestamp < 0 {
+				timestamp = 0
+			}
+			lines = append(lines, LyricsLine{Timestamp: timestamp, Text: strings.TrimSpace(line)})
+		}
+	}
+
+	order := make([]int, len(lines))
+	for index := range order {
+		order[index] = index
+	}
+	slice.Sort(order, func(i, j int) bool {
+		if lines[order[i]].Timestamp != lines[order[j]].Timestamp {
+			return lines[order[i]].Timestamp < lines[order[j]].Timestamp
+		}
+		return order[i] < order[j]
+	})
+	sortedLines := make(LyricsLines, len(lines))
+	for index, lineIndex := range order {
+		sortedLines[index] = lines[lineIndex]
+	}
+	return sortedLines, nil
+}
+
+// Validate : return nil error if synced lyrics lines are chronologically ordered and fit into
+// input duration, in seconds, if known
+func (lines LyricsLines) Validate(duration int) error {
+	if len(lines) == 0 {
+		return fmt.Errorf("No synced lyrics line found")
+	}
+	for lineIndex, line := range lines {
+		if line.Timestamp < 0 {
+			return fmt.Errorf(fmt.Sprintf("Line %d has a negative timestamp", lineIndex+1))
+		}
+		if lineIndex > 0 && line.Timestamp < lines[lineIndex-1].Timestamp {
+			return fmt.Errorf(fmt.Sprintf("Line %d is not in chronological order", lineIndex+1))
+		}
+		// duration is truncated to seconds, hence the last one is given entirely
+		if duration > 0 && line.Timestamp >= (duration+1)*1000 {
+			return fmt.Errorf(fmt.Sprintf("Line %d timestamp %s exceeds song duration of %ds",
+				lineIndex+1, line.TimestampString(), duration))
+		}
+	}
+	return nil
+}
+
+// String : return synced lyrics lines as LRC text
+func (lines LyricsLines) String() string {
+	var text []string
+	for _, line := range lines {
+		text = append(text, "["+line.TimestampString()+"]"+line.Text)
+	}
+	return strings.Join(text, "\n")
+}
+
+// Text : return synced lyrics lines text, without timestamps
+func (lines LyricsLines) Text() string {
+	var text []string
+	for _, line := range lines {
+		text = append(text, line.Text)
+	}
+	return strings.Join(text, "\n")
+}
+
+// DetectLanguage : return ISO 639-2 code of the language input lyrics are written in, detecting it
+// by their script or, if Latin, by their stopwords, falling back to undetermined one
+func DetectLanguage(lyrics string) string {
+	var (
+		scripts = make(map[string]int)
+		kana    int
+		latin   int
+	)
+	for _, r := range lyrics {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			kana++
+		} else if unicode.Is(unicode.Latin, r) {
+			latin++
+		} else {
+			for _, script := range lyricsScripts {
+				if unicode.Is(script.Script, r) {
+					scripts[script.Language]++
+					break
+				}
+			}
+		}
+	}
+	if kana > 0 && kana+scripts["zho"] > latin {
+		return "jpn"
+	}
+	for _, script := range lyricsScripts {
+		if scripts[script.Language] > latin {
+			if script.Language == "rus" && strings.ContainsAny(strings.ToLower(lyrics), "іїєґ") {
+				return "ukr"
+			}
+			return script.Language
+		}
+	}
+	return detectLatinLanguage(lyrics)
+}
+
+// TransliterateLyrics : return Latin transliteration of input lyrics, keeping their lines and sections
+func TransliterateLyrics(lyrics string) string {
+	var lines []string
+	for _, line := range strings.Split(lyrics, "\n") {
+		lines = append(lines, strings.TrimSpace(unidecode.Unidecode(line)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TimestampString : return synced lyrics line timestamp as LRC mm:ss.xx sequence
+func (line LyricsLine) TimestampString() string {
+	return fmt.Sprintf("%02d:%02d.%02d", line.Timestamp/60000, line.Timestamp/1000%60, line.Timestamp%1000/10)
 }
 
 // SeemsType : return True if input sequence matches with selected input songType variant
 func SeemsType(sequence string, songType int) bool {
-	var songTypeAliases []string
-	if songType == SongTypeLive {
-		songTypeAliases = []string{"@", "live", "perform", "tour"}
-		for _, year := range spttb_system.MakeRange(1950, 2050) {
-			songTypeAliases = append(songTypeAliases, []string{strconv.Itoa(year), "'" + strconv.Itoa(year)[2:]}...)
-		}
-	} else if songType == SongTypeCover {
-		songTypeAliases = []string{"cover", "vs", "amateur"}
-	} else if songType == SongTypeRemix {
-		songTypeAliases = []string{"remix", "radio edit"}
-	} else if songType == SongTypeAcoustic {
-		songTypeAliases = []string{"acoustic"}
-	} else if songType == SongTypeKaraoke {
-		songTypeAliases = []string{"karaoke", "instrumental"}
-	} else if songType == SongTypeParody {
-		songTypeAliases = []string{"parody"}
-	} else if songType == SongTypeReverse {
-		songTypeAliases = []string{"reverse"}
-	}
-
-	for _, songTypeAlias := range songTypeAliases {
-		sequence = strings.ToLower(sequence)
-		if len(songTypeAlias) != 1 {
-			sequence = sanitize.Name(sequence)
-		}
-		if len(sanitize.Name(strings.ToLower(songTypeAlias))) == len(songTypeAlias) {
-			songTypeAlias = sanitize.Name(strings.ToLower(songTypeAlias))
-		}
-		if strings.Contains(sequence, songTypeAlias) {
-			return true
-		}
-	}
-	return false
+	return containsString(matchVersions(sequence), SongTypeVersions[songType])
+}
+
+// LoadVersionRules : load version rules from input JSON rules file
+func LoadVersionRules(path string) (VersionRules, error) {
+	var rules VersionRules
+	rulesBytes, rulesErr := ioutil.ReadFile(path)
+	if rulesErr != nil {
+		return rules, fmt.Errorf(fmt.Sprintf("Unable to read version rules from \"%s\": %s", path, rulesErr.Error()))
+	}
+	if rulesErr := json.Unmarshal(rulesBytes, &rules); rulesErr != nil {
+		return rules, fmt.Errorf(fmt.Sprintf("Unable to parse version rules from \"%s\": %s", path, rulesErr.Error()))
+	}
+	return rules, rules.Validate()
+}
+
+// Validate : return nil error if every rule is named, has aliases and a known policy
+func (rules VersionRules) Validate() error {
+	for _, rule := range rules {
+		if len(strings.TrimSpace(rule.Name)) == 0 {
+			return fmt.Errorf("Version rule without name")
+		}
+		if len(rule.Aliases) == 0 {
+			return fmt.Errorf(fmt.Sprintf("Version rule \"%s\" has no alias", rule.Name))
+		}
+		switch rule.Policy {
+		case VersionPolicyStrict, VersionPolicyRequire, VersionPolicyReject, VersionPolicyIgnore:
+		default:
+			return fmt.Errorf(fmt.Sprintf("Version rule \"%s\" has unknown policy \"%s\"", rule.Name, rule.Policy))
+		}
+	}
+	return nil
+}
+
+// Dump : write version rules into input JSON rules file
+func (rules VersionRules) Dump(path string) error {
+	rulesBytes, rulesErr := json.MarshalIndent(rules, "", "\t")
+	if rulesErr != nil {
+		return rulesErr
+	}
+	return ioutil.WriteFile(path, rulesBytes, 0644)
+}
+
+// TagGetFrameReplayGain : get ReplayGain value frame, identified by description, from input Tag
+func TagGetFrameReplayGain(tag *id3v2.Tag, description string) string {
+	return tagGetUserText(tag, description)
+}
+
+// GetFrame : get input frame from ID3 tag
+func (tagger *id3Tagger) GetFrame(frame int) string {
+	return TagGetFrame(tagger.tag, frame)
+}
+
+// HasFrame : return True if ID3 tag has valued input frame
+func (tagger *id3Tagger) HasFrame(frame int) bool {
+	return TagHasFrame(tagger.tag, frame)
+}
+
+// SetFrame : set input frame value into ID3 tag, as standard frame, dropping its legacy comment, if any
+func (tagger *id3Tagger) SetFrame(frame int, value string) {
+	switch frame {
+	case ID3FrameTitle:
+		tagger.tag.SetTitle(value)
+	case ID3FrameArtist:
+		tagger.tag.SetArtist(value)
+	case ID3FrameAlbum:
+		tagger.tag.SetAlbum(value)
+	case ID3FrameGenre:
+		tagger.tag.SetGenre(value)
+	case ID3FrameYear:
+		tagger.tag.SetYear(value)
+	case ID3FrameAlbumArtist:
+		tagger.tag.AddTextFrame("TPE2", id3v2.EncodingUTF8, value)
+	case ID3FrameDiscNumber:
+		tagger.tag.AddTextFrame("TPOS", id3v2.EncodingUTF8, value)
+	case ID3FrameISRC:
+		tagger.tag.AddTextFrame("TSRC", id3v2.EncodingUTF8, value)
+	case ID3FrameTrackNumber:
+		if trackTotals := TagGetFrameTrackTotals(tagger.tag); len(trackTotals) > 0 {
+			value += "/" + trackTotals
+		}
+		tagger.tag.AddTextFrame("TRCK", id3v2.EncodingUTF8, value)
+	case ID3FrameTrackTotals:
+		if trackNumber := TagGetFrameTrackNumber(tagger.tag); len(trackNumber) > 0 {
+			tagger.tag.AddTextFrame("TRCK", id3v2.EncodingUTF8, trackNumber+"/"+value)
+		}
+	case ID3FrameArtworkURL:
+		tagSetUserURL(tagger.tag, tagFrameDescriptions[frame], value)
+	case ID3FrameYouTubeURL:
+		tagger.tag.DeleteFrames("WOAS")
+		tagger.tag.AddFrame("WOAS", urlFrame{URL: value})
+	case ID3FrameDuration:
+		if duration, durationErr := strconv.Atoi(value); durationErr == nil {
+			tagger.tag.AddTextFrame("TLEN", id3v2.EncodingUTF8, strconv.Itoa(duration*1000))
+		}
+	case ID3FrameSpotifyID:
+		tagger.tag.AddUFIDFrame(id3v2.UFIDFrame{
+			OwnerIdentifier: TagUFIDOwner,
+			Identifier:      []byte(value),
+		})
+	case ID3FrameLyrics, ID3FrameLyricsTransliteration:
+		var (
+			transliteration = TagGetFrameLyricsTransliteration(tagger.tag)
+			lyrics          = TagGetFrameLyrics(tagger.tag)
+			descriptor      = tagger.tag.Title()
+		)
+		if frame == ID3FrameLyrics {
+			lyrics = value
+		} else {
+			transliteration = value
+		}
+		if descriptor == LyricsTransliterationDescriptor {
+			descriptor = ""
+		}
+		tagger.tag.DeleteFrames(tagger.tag.CommonID("Unsynchronised lyrics/text transcription"))
+		if len(lyrics) > 0 {
+			tagger.tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+				Encoding:          id3v2.EncodingUTF8,
+				Language:          DetectLanguage(lyrics),
+				ContentDescriptor: descriptor,
+				Lyrics:            lyrics,
+			})
+		}
+		if len(transliteration) > 0 {
+			tagger.tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+				Encoding:          id3v2.EncodingUTF8,
+				Language:          DetectLanguage(lyrics),
+				ContentDescriptor: LyricsTransliterationDescriptor,
+				Lyrics:            transliteration,
+			})
+		}
+	case ID3FrameSyncedLyrics:
+		tagger.tag.DeleteFrames("SYLT")
+		if lines, linesErr := ParseLRC(value); linesErr == nil && len(lines) > 0 {
+			tagger.tag.AddFrame("SYLT", syncedLyricsFrame{Language: DetectLanguage(lines.Text()), Lines: lines})
+		}
+	default:
+		if description, ok := tagFrameDescriptions[frame]; ok {
+			tagger.tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+				Encoding:    id3v2.EncodingUTF8,
+				Description: description,
+				Value:       value,
+			})
+		}
+	}
+	if description, ok := tagFrameDescriptions[frame]; ok {
+		tagDeleteComment(tagger.tag, description)
+	}
+}
+
+// SetArtwork : set input front cover picture into ID3 tag
+func (tagger *id3Tagger) SetArtwork(mimeType string, picture []byte) {
+	tagger.tag.AddAttachedPicture(id3v2.PictureFrame{
+		Encoding:    id3v2.EncodingUTF8,
+		MimeType:    mimeType,
+		PictureType: id3v2.PTFrontCover,
+		Description: "Front cover",
+		Picture:     picture,
+	})
+}
+
+// DeleteFrames : drop every frame from ID3 tag
+func (tagger *id3Tagger) DeleteFrames() {
+	tagger.tag.DeleteAllFrames()
+}
+
+// Save : write ID3 tag into its song
+func (tagger *id3Tagger) Save() error {
+	return tagger.tag.Save()
+}
+
+// Close : close ID3 tag song file
+func (tagger *id3Tagger) Close() error {
+	return tagger.tag.Close()
+}
+
+func (tagger *ffmpegTagger) key(frame int) (string, bool) {
+	if tagger.format == TagFormatVorbis {
+		if key, ok := tagVorbisKeys[frame]; ok {
+			return strings.ToLower(key), false
+		}
+		return strings.ToLower(tagFrameDescriptions[frame]), false
+	}
+	if key, ok := tagMP4Keys[frame]; ok {
+		return key, false
+	} else if key, ok := tagMP4PackedKeys[frame]; ok {
+		return key, true
+	}
+	return tagFrameDescriptions[frame], true
+}
+
+// GetFrame : get input frame from Vorbis comments or MP4 atoms
+func (tagger *ffmpegTagger) GetFrame(frame int) string {
+	if frame == ID3FrameArtwork {
+		if !tagger.pictureSet && tagger.pictureFound {
+			tagger.picture, _ = extractPicture(tagger.path)
+		}
+		return string(tagger.picture)
+	}
+
+	var value string
+	if key, packed := tagger.key(frame); len(key) == 0 {
+		return ""
+	} else if packed {
+		value = tagger.comments[key]
+	} else {
+		value = tagger.frames[key]
+	}
+	if frame == ID3FrameTrackNumber || frame == ID3FrameDiscNumber {
+		return strings.Split(value, "/")[0]
+	} else if frame == ID3FrameTrackTotals && len(value) == 0 && strings.Contains(tagger.frames[tagMP4Keys[ID3FrameTrackNumber]], "/") {
+		return strings.SplitN(tagger.frames[tagMP4Keys[ID3FrameTrackNumber]], "/", 2)[1]
+	}
+	return value
+}
+
+// HasFrame : return True if Vorbis comments or MP4 atoms have valued input frame
+func (tagger *ffmpegTagger) HasFrame(frame int) bool {
+	if frame == ID3FrameArtwork {
+		return tagger.pictureSet || tagger.pictureFound
+	}
+	return tagger.GetFrame(frame) != ""
+}
+
+// SetFrame : set input frame value into Vorbis comments or MP4 atoms
+func (tagger *ffmpegTagger) SetFrame(frame int, value string) {
+	if key, packed := tagger.key(frame); len(key) == 0 {
+		return
+	} else if packed {
+		tagger.comments[key] = value
+	} else {
+		tagger.frames[key] = value
+	}
+}
+
+// SetArtwork : set input front cover picture into Vorbis comments or MP4 atoms
+func (tagger *ffmpegTagger) SetArtwork(mimeType string, picture []byte) {
+	tagger.picture, tagger.pictureMime, tagger.pictureSet = picture, mimeType, true
+}
+
+// DeleteFrames : drop every frame from Vorbis comments or MP4 atoms
+func (tagger *ffmpegTagger) DeleteFrames() {
+	tagger.frames = make(map[string]string)
+	tagger.comments = make(map[string]string)
+	tagger.picture, tagger.pictureMime, tagger.pictureSet, tagger.pictureFound = nil, "", false, false
+}
+
+// Save : write Vorbis comments or MP4 atoms into their song, remuxing it via ffmpeg
+func (tagger *ffmpegTagger) Save() error {
+	var (
+		taggerDir, taggerBase = filepath.Split(tagger.path)
+		metadataPath          = filepath.Join(taggerDir, "."+taggerBase+".ffmetadata")
+		picturePath           = filepath.Join(taggerDir, "."+taggerBase+".cover")
+		outputPath            = filepath.Join(taggerDir, "."+taggerBase+".tagging"+filepath.Ext(taggerBase))
+		frames                = make(map[string]string)
+		framesKeys            []string
+		metadata              = ";FFMETADATA1\n"
+	)
+	if len(ActiveWorkspace) > 0 {
+		workspaceDir, err := ioutil.TempDir(ActiveWorkspace, "tagging-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(workspaceDir)
+		metadataPath = filepath.Join(workspaceDir, taggerBase+".ffmetadata")
+		picturePath = filepath.Join(workspaceDir, taggerBase+".cover")
+		outputPath = filepath.Join(workspaceDir, taggerBase)
+	}
+	defer os.Remove(metadataPath)
+	defer os.Remove(picturePath)
+	defer os.Remove(outputPath)
+
+	for key, value := range tagger.frames {
+		frames[key] = value
+	}
+	if tagger.format == TagFormatVorbis {
+		if !tagger.pictureSet && tagger.pictureFound {
+			if picture, pictureErr := extractPicture(tagger.path); pictureErr == nil {
+				tagger.SetArtwork(http.DetectContentType(picture), picture)
+			}
+		}
+		if tagger.pictureSet && len(tagger.picture) > 0 {
+			frames[strings.ToLower(TagVorbisPictureKey)] = vorbisPictureBlock(tagger.pictureMime, tagger.picture)
+		}
+	} else {
+		frames[TagMP4CommentKey] = packMP4Comment(frames[TagMP4CommentKey], tagger.comments)
+		trackNumberKey := tagMP4Keys[ID3FrameTrackNumber]
+		if trackTotals := tagger.comments[tagFrameDescriptions[ID3FrameTrackTotals]]; len(trackTotals) > 0 && len(frames[trackNumberKey]) > 0 {
+			frames[trackNumberKey] = strings.Split(frames[trackNumberKey], "/")[0] + "/" + trackTotals
+		}
+	}
+
+	for key := range frames {
+		framesKeys = append(framesKeys, key)
+	}
+	slice.Sort(framesKeys, func(i, j int) bool {
+		return framesKeys[i] < framesKeys[j]
+	})
+	for _, key := range framesKeys {
+		if len(frames[key]) == 0 {
+			continue
+		}
+		if tagger.format == TagFormatVorbis {
+			metadata += ffmetadataEscape(strings.ToUpper(key)) + "=" + ffmetadataEscape(frames[key]) + "\n"
+		} else {
+			metadata += ffmetadataEscape(key) + "=" + ffmetadataEscape(frames[key]) + "\n"
+		}
+	}
+	if err := ioutil.WriteFile(metadataPath, []byte(metadata), 0644); err != nil {
+		return err
+	}
+
+	commandCmd := "ffmpeg"
+	commandArgs := []string{"-nostdin", "-y", "-i", tagger.path, "-f", "ffmetadata", "-i", metadataPath}
+	if tagger.format == TagFormatMP4 && tagger.pictureSet && len(tagger.picture) > 0 {
+		if err := ioutil.WriteFile(picturePath, tagger.picture, 0644); err != nil {
+			return err
+		}
+		commandArgs = append(commandArgs, "-i", picturePath, "-map", "0:a", "-map", "2:v", "-disposition:v:0", "attached_pic")
+	} else if tagger.format == TagFormatMP4 && !tagger.pictureSet && tagger.pictureFound {
+		commandArgs = append(commandArgs, "-map", "0:a", "-map", "0:v")
+	} else {
+		commandArgs = append(commandArgs, "-map", "0:a")
+	}
+	commandArgs = append(commandArgs, "-c", "copy", "-map_metadata", "1")
+	if tagger.format == TagFormatVorbis {
+		commandArgs = append(commandArgs, "-map_metadata:s:a", "1:g")
+	}
+	commandArgs = append(commandArgs, outputPath)
+	if commandOut, commandErr := exec.Command(commandCmd, commandArgs...).CombinedOutput(); commandErr != nil {
+		return fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), string(commandOut)))
+	}
+	return spttb_system.FileMove(outputPath, tagger.path)
+}
+
+// Close : release Vorbis comments or MP4 atoms, nothing being kept open
+func (tagger *ffmpegTagger) Close() error {
+	return nil
+}
+
+// Size : return WOAS frame body size
+func (frame urlFrame) Size() int {
+	return len(frame.URL)
+}
+
+// UniqueIdentifier : return WOAS frame identifier, as it can be stored just once
+func (frame urlFrame) UniqueIdentifier() string {
+	return ""
+}
+
+// WriteTo : write WOAS frame body into input writer
+func (frame urlFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, frame.URL)
+	return int64(n), err
+}
+
+// Size : return WXXX frame body size
+func (frame userURLFrame) Size() int {
+	return 1 + len(frame.Description) + 1 + len(frame.URL)
+}
+
+// UniqueIdentifier : return WXXX frame identifier, its description
+func (frame userURLFrame) UniqueIdentifier() string {
+	return frame.Description
+}
+
+// WriteTo : write WXXX frame body, ISO-8859-1 encoded, into input writer
+func (frame userURLFrame) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(append(append(append([]byte{id3v2.EncodingISO.Key}, frame.Description...), 0), frame.URL...))
+	return int64(n), err
+}
+
+// Size : return SYLT frame body size
+func (frame syncedLyricsFrame) Size() int {
+	size := 1 + 3 + 1 + 1 + len(encodeUTF16("")) + 2
+	for _, line := range frame.Lines {
+		size += len(encodeUTF16(line.Text)) + 2 + 4
+	}
+	return size
+}
+
+// UniqueIdentifier : return SYLT frame identifier, its language
+func (frame syncedLyricsFrame) UniqueIdentifier() string {
+	return frame.Language
+}
+
+// WriteTo : write SYLT frame body, UTF-16 encoded and millisecond timestamped, into input writer
+func (frame syncedLyricsFrame) WriteTo(w io.Writer) (int64, error) {
+	body := append([]byte{id3v2.EncodingUTF16.Key}, frame.Language...)
+	body = append(body, TagSYLTTimestampMilliseconds, TagSYLTContentLyrics)
+	body = append(append(body, encodeUTF16("")...), 0, 0)
+	for _, line := range frame.Lines {
+		body = append(append(body, encodeUTF16(line.Text)...), 0, 0)
+		body = append(body, byte(line.Timestamp>>24), byte(line.Timestamp>>16), byte(line.Timestamp>>8), byte(line.Timestamp))
+	}
+	n, err := w.Write(body)
+	return int64(n), err
 }
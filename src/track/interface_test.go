@@ -0,0 +1,1051 @@
+package track
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	spttb_system "system"
+
+	"github.com/bogem/id3v2"
+	"github.com/zmb3/spotify"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, fixture := range []struct {
+		sequence string
+		expected string
+	}{
+		{"Mr. Brightside", "mr brightside"},
+		{"Don't Stop Me Now", "dont stop me now"},
+		{"Beyoncé - Halo", "beyoncé halo"},
+		{"Кино - Группа крови", "кино группа крови"},
+		{"宇多田ヒカル - First Love", "宇多田ヒカル first love"},
+		{"ｱｲｳ　ＡＢＣ", "アイウ abc"},
+		{"방탄소년단 - 봄날", "방탄소년단 봄날"},
+		{"Μίκης Θεοδωράκης", "μίκης θεοδωράκης"},
+		{"عمرو دياب - تملي معاك", "عمرو دياب تملي معاك"},
+	} {
+		if normalized := Normalize(fixture.sequence); normalized != fixture.expected {
+			t.Errorf("Normalize(%q) = %q, expected %q", fixture.sequence, normalized, fixture.expected)
+		}
+	}
+}
+
+func TestTransliterate(t *testing.T) {
+	for _, fixture := range []struct {
+		sequence string
+		expected string
+	}{
+		{"Beyoncé - Halo", "beyonce halo"},
+		{"Кино - Группа крови", "kino gruppa krovi"},
+		{"방탄소년단 - 봄날", "bangtansonyeondan bomnal"},
+		{"Μίκης Θεοδωράκης", "mikes theodorakes"},
+	} {
+		if transliterated := Transliterate(fixture.sequence); transliterated != fixture.expected {
+			t.Errorf("Transliterate(%q) = %q, expected %q", fixture.sequence, transliterated, fixture.expected)
+		}
+	}
+}
+
+func TestSeemsByWordMatch(t *testing.T) {
+	for _, fixture := range []struct {
+		track    Track
+		sequence string
+		matching bool
+	}{
+		{Track{Song: "Halo", Artist: "Beyoncé"}, "Beyonce - Halo (Official Video)", true},
+		{Track{Song: "Группа крови", Artist: "Кино"}, "Кино - Группа крови (official audio)", true},
+		{Track{Song: "Группа крови", Artist: "Кино"}, "Kino - Gruppa krovi", true},
+		{Track{Song: "Группа крови", Artist: "Кино"}, "Кино - Кукушка", false},
+		{Track{Song: "First Love", Artist: "宇多田ヒカル"}, "宇多田ヒカル - First Love (Official Video)", true},
+		{Track{Song: "First Love", Artist: "宇多田ヒカル"}, "中島美嘉 - First Love", false},
+		{Track{Song: "봄날", Artist: "방탄소년단"}, "BTS (방탄소년단) '봄날 (Spring Day)' Official MV", true},
+		{Track{Song: "봄날", Artist: "방탄소년단"}, "방탄소년단 - 피 땀 눈물", false},
+		{Track{Song: "Τα παιδιά του Πειραιά", Artist: "Μελίνα Μερκούρη"}, "Μελίνα Μερκούρη - Τα παιδιά του Πειραιά", true},
+		{Track{Song: "Τα παιδιά του Πειραιά", Artist: "Μελίνα Μερκούρη"}, "Νάνα Μούσχουρη - Τα παιδιά του Πειραιά", false},
+		{Track{Song: "تملي معاك", Artist: "عمرو دياب"}, "عمرو دياب - تملي معاك", true},
+		{Track{Song: "تملي معاك", Artist: "عمرو دياب"}, "عمرو دياب - نور العين", false},
+	} {
+		err := fixture.track.SeemsByWordMatch(fixture.sequence)
+		if fixture.matching && err != nil {
+			t.Errorf("%s by %s expected to match %q: %s", fixture.track.Song, fixture.track.Artist, fixture.sequence, err.Error())
+		} else if !fixture.matching && err == nil {
+			t.Errorf("%s by %s expected not to match %q", fixture.track.Song, fixture.track.Artist, fixture.sequence)
+		}
+	}
+}
+
+func TestSeemsType(t *testing.T) {
+	for _, fixture := range []struct {
+		sequence string
+		songType int
+		expected bool
+	}{
+		{"Кино - Группа крови (live)", SongTypeLive, true},
+		{"Кино - Группа крови", SongTypeLive, false},
+		{"宇多田ヒカル - First Love (Acoustic)", SongTypeAcoustic, true},
+		{"방탄소년단 - 봄날 (Remix)", SongTypeRemix, true},
+		{"방탄소년단 - 봄날", SongTypeRemix, false},
+	} {
+		if seems := SeemsType(fixture.sequence, fixture.songType); seems != fixture.expected {
+			t.Errorf("SeemsType(%q, %d) = %t, expected %t", fixture.sequence, fixture.songType, seems, fixture.expected)
+		}
+	}
+}
+
+func TestSeemsVersion(t *testing.T) {
+	for _, fixture := range []struct {
+		track    Track
+		sequence string
+		matching bool
+	}{
+		{Track{Song: "Heroes", Artist: "David Bowie", Versions: []string{VersionRemaster}}, "David Bowie - Heroes (Official Video)", true},
+		{Track{Song: "Heroes", Artist: "David Bowie"}, "David Bowie - Heroes (2017 Remaster)", true},
+		{Track{Song: "Heat Waves", Artist: "Glass Animals", Versions: []string{VersionSpedUp}}, "Glass Animals - Heat Waves (sped up)", true},
+		{Track{Song: "Heat Waves", Artist: "Glass Animals", Versions: []string{VersionSpedUp}}, "Glass Animals - Heat Waves", false},
+		{Track{Song: "Heat Waves", Artist: "Glass Animals"}, "Glass Animals - Heat Waves (slowed + reverb)", false},
+		{Track{Song: "Heat Waves", Artist: "Glass Animals"}, "Heat Waves - Nightcore", false},
+		{Track{Song: "Live Forever", Artist: "Oasis"}, "Oasis - Live Forever (Official Video)", true},
+		{Track{Song: "Live Forever", Artist: "Oasis"}, "Oasis - Live Forever (Live at Knebworth)", false},
+		{Track{Song: "Yellow", Artist: "Coldplay"}, "Coldplay - Yellow (Demo)", false},
+		{Track{Song: "Levels", Artist: "Avicii", Versions: []string{VersionRadioEdit}}, "Avicii - Levels", true},
+	} {
+		err := fixture.track.SeemsVersion(fixture.sequence)
+		if fixture.matching && err != nil {
+			t.Errorf("%s by %s expected to match %q: %s", fixture.track.Song, fixture.track.Artist, fixture.sequence, err.Error())
+		} else if !fixture.matching && err == nil {
+			t.Errorf("%s by %s expected not to match %q", fixture.track.Song, fixture.track.Artist, fixture.sequence)
+		}
+	}
+}
+
+func TestVersionRulesValidate(t *testing.T) {
+	if err := DefaultVersionRules.Validate(); err != nil {
+		t.Errorf("default version rules expected to be valid: %s", err.Error())
+	}
+	if err := (VersionRules{{Name: VersionLive, Aliases: []string{"live"}, Policy: "maybe"}}).Validate(); err == nil {
+		t.Errorf("version rule with unknown policy expected to be invalid")
+	}
+}
+
+func TestFFmpegTaggerFrames(t *testing.T) {
+	vorbis := &ffmpegTagger{format: TagFormatVorbis, frames: map[string]string{"tracknumber": "3/12"}, comments: map[string]string{}}
+	vorbis.SetFrame(ID3FrameTitle, "Halo")
+	vorbis.SetFrame(ID3FrameSpotifyID, "4JehYebiI9JE8sR8MisGVb")
+	if vorbis.frames["title"] != "Halo" || vorbis.frames["spotifyid"] != "4JehYebiI9JE8sR8MisGVb" {
+		t.Errorf("Vorbis frames = %v", vorbis.frames)
+	}
+	if trackNumber := vorbis.GetFrame(ID3FrameTrackNumber); trackNumber != "3" {
+		t.Errorf("Vorbis track number = %q, expected \"3\"", trackNumber)
+	}
+
+	mp4 := &ffmpegTagger{format: TagFormatMP4, frames: map[string]string{"track": "3/12"}, comments: map[string]string{}}
+	mp4.SetFrame(ID3FrameYouTubeURL, "https://youtu.be/bnVUHWCynig")
+	if mp4.comments["youtube"] != "https://youtu.be/bnVUHWCynig" || len(mp4.frames) != 1 {
+		t.Errorf("MP4 custom frames expected to be packed into comment: %v, %v", mp4.frames, mp4.comments)
+	}
+	if trackTotals := mp4.GetFrame(ID3FrameTrackTotals); trackTotals != "12" {
+		t.Errorf("MP4 track totals = %q, expected \"12\"", trackTotals)
+	}
+	if mp4.HasFrame(ID3FrameArtwork) {
+		t.Errorf("MP4 tags without pictures expected not to have artwork")
+	}
+
+	syncedLyrics := "[00:17.51] Remember those walls I built?\n[00:21.12] Well, baby, they're \\ tumbling down"
+	mp4.SetFrame(ID3FrameSyncedLyrics, syncedLyrics)
+	mp4.SetFrame(ID3FrameLyricsTransliteration, "Remember those walls")
+	comment := packMP4Comment("Downloaded by hand", mp4.comments)
+	if strings.Count(comment, "\n") != 3 {
+		t.Errorf("MP4 packed comment expected to hold one line per frame: %q", comment)
+	}
+	reloaded := &ffmpegTagger{format: TagFormatMP4, frames: map[string]string{}}
+	reloaded.frames[TagMP4CommentKey], reloaded.comments = unpackMP4Comment(comment)
+	for frame, expected := range map[int]string{
+		ID3FrameYouTubeURL:            "https://youtu.be/bnVUHWCynig",
+		ID3FrameSyncedLyrics:          syncedLyrics,
+		ID3FrameLyricsTransliteration: "Remember those walls",
+	} {
+		if value := reloaded.GetFrame(frame); value != expected {
+			t.Errorf("MP4 reloaded frame %d = %q, expected %q", frame, value, expected)
+		}
+	}
+	if reloaded.frames[TagMP4CommentKey] != "Downloaded by hand" {
+		t.Errorf("MP4 reloaded comment = %q, expected user comment to be kept", reloaded.frames[TagMP4CommentKey])
+	}
+}
+
+func TestTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Beyonce - Halo.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := LoadTags(path, true)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	tags.Set(ID3FrameTitle, "Halo")
+	tags.Set(ID3FrameSpotifyID, "4JehYebiI9JE8sR8MisGVb")
+	tags.Set(ID3FrameTrackGain, "-8.17 dB")
+	tags.SetArtwork("image/jpeg", []byte{0xff, 0xd8, 0xff, 0xe0})
+	if err := tags.Save(); err != nil {
+		t.Fatalf("tags expected to be saved: %s", err.Error())
+	}
+	tags.Close()
+
+	tags, err = LoadTags(path, false)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	defer tags.Close()
+	for frame, expected := range map[int]string{
+		ID3FrameTitle:     "Halo",
+		ID3FrameSpotifyID: "4JehYebiI9JE8sR8MisGVb",
+		ID3FrameTrackGain: "-8.17 dB",
+		ID3FrameLyrics:    "",
+	} {
+		if value := tags.Get(frame); value != expected {
+			t.Errorf("frame %d = %q, expected %q", frame, value, expected)
+		}
+	}
+	if !tags.Partial || tags.Save() == nil {
+		t.Errorf("tags loaded without pictures expected to be read-only")
+	}
+	if track := (Track{Filename: filepath.Join(dir, "Beyonce - Halo"), FilenameExt: ".mp3"}); !track.HasID3Frame(ID3FrameArtwork) {
+		t.Errorf("tags expected to have artwork")
+	}
+	if track, artwork, err := OpenLocalTrackArtwork(path); err != nil || !artwork || track.SpotifyID != "4JehYebiI9JE8sR8MisGVb" {
+		t.Errorf("local track expected to be opened along with its artwork, got artwork: %t, error: %v", artwork, err)
+	}
+}
+
+func TestTagsStandardFrames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Beyonce - Halo.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag.AddTextFrame("TRCK", tag.DefaultEncoding(), "3")
+	for description, text := range map[string]string{
+		"trackTotals": "12",
+		"youtube":     "https://youtu.be/bnVUHWCynig",
+		"duration":    "261",
+		"spotifyid":   "4JehYebiI9JE8sR8MisGVb",
+	} {
+		tag.AddCommentFrame(id3v2.CommentFrame{
+			Encoding:    tag.DefaultEncoding(),
+			Language:    "eng",
+			Description: description,
+			Text:        text,
+		})
+	}
+	if err := tag.Save(); err != nil {
+		t.Fatal(err)
+	}
+	tag.Close()
+
+	expected := map[int]string{
+		ID3FrameTrackNumber: "3",
+		ID3FrameTrackTotals: "12",
+		ID3FrameYouTubeURL:  "https://youtu.be/bnVUHWCynig",
+		ID3FrameDuration:    "261",
+		ID3FrameSpotifyID:   "4JehYebiI9JE8sR8MisGVb",
+	}
+	tags, err := LoadTags(path, true)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	if len(tags.Legacy) != 4 {
+		t.Errorf("tags expected to have 4 legacy frames, got %v", tags.Legacy)
+	}
+	for _, frame := range tags.Legacy {
+		tags.Set(frame, tags.Get(frame))
+	}
+	tags.Set(ID3FrameArtworkURL, "https://i.scdn.co/image/halo")
+	tags.Set(ID3FrameAlbumArtist, "Beyoncé")
+	tags.Set(ID3FrameDiscNumber, "1")
+	if err := tags.Save(); err != nil {
+		t.Fatalf("tags expected to be saved: %s", err.Error())
+	}
+	tags.Close()
+	expected[ID3FrameArtworkURL] = "https://i.scdn.co/image/halo"
+	expected[ID3FrameAlbumArtist] = "Beyoncé"
+	expected[ID3FrameDiscNumber] = "1"
+
+	tags, err = LoadTags(path, false)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	if len(tags.Legacy) > 0 {
+		t.Errorf("tags expected to have no legacy frames left, got %v", tags.Legacy)
+	}
+	for frame, value := range expected {
+		if tags.Get(frame) != value {
+			t.Errorf("frame %d = %q, expected %q", frame, tags.Get(frame), value)
+		}
+	}
+	tags.Close()
+
+	tag, err = id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	for id, value := range map[string]string{
+		"TRCK": "3/12",
+		"TLEN": "261000",
+		"TPE2": "Beyoncé",
+		"TPOS": "1",
+	} {
+		if text := tag.GetTextFrame(id).Text; text != value {
+			t.Errorf("%s = %q, expected %q", id, text, value)
+		}
+	}
+	if len(tag.GetFrames(tag.CommonID("Comments"))) > 0 {
+		t.Errorf("legacy comment frames expected to be dropped")
+	}
+	if len(tag.GetFrames("UFID")) != 1 || len(tag.GetFrames("WOAS")) != 1 || len(tag.GetFrames("WXXX")) != 1 {
+		t.Errorf("UFID, WOAS and WXXX frames expected to be written once")
+	}
+}
+
+func TestParseLRC(t *testing.T) {
+	lines, err := ParseLRC("[ar:Beyonce]\n[offset:+500]\n[00:12.50]Remember those walls I built\n\n[00:10.00][01:02.3]Halo\r\n[00:20.123]Baby, they're tumbling down\n")
+	if err != nil {
+		t.Fatalf("LRC expected to be parsed: %s", err.Error())
+	}
+	expected := LyricsLines{
+		{Timestamp: 9500, Text: "Halo"},
+		{Timestamp: 12000, Text: "Remember those walls I built"},
+		{Timestamp: 19623, Text: "Baby, they're tumbling down"},
+		{Timestamp: 61800, Text: "Halo"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("ParseLRC returned %d lines, expected %d", len(lines), len(expected))
+	}
+	for index, line := range lines {
+		if line != expected[index] {
+			t.Errorf("line %d = %+v, expected %+v", index, line, expected[index])
+		}
+	}
+	if text := lines.String(); text != "[00:09.50]Halo\n[00:12.00]Remember those walls I built\n[00:19.62]Baby, they're tumbling down\n[01:01.80]Halo" {
+		t.Errorf("String() = %q", text)
+	}
+
+	for _, text := range []string{"Halo", "[00:61.00]Halo"} {
+		if _, err := ParseLRC(text); err == nil {
+			t.Errorf("ParseLRC(%q) expected to fail", text)
+		}
+	}
+}
+
+func TestLyricsLinesValidate(t *testing.T) {
+	for _, fixture := range []struct {
+		lines    LyricsLines
+		duration int
+		valid    bool
+	}{
+		{LyricsLines{{Timestamp: 1000, Text: "Halo"}, {Timestamp: 261500, Text: "Halo"}}, 261, true},
+		{LyricsLines{{Timestamp: 1000, Text: "Halo"}, {Timestamp: 262000, Text: "Halo"}}, 261, false},
+		{LyricsLines{{Timestamp: 1000, Text: "Halo"}, {Timestamp: 262000, Text: "Halo"}}, 0, true},
+		{LyricsLines{{Timestamp: 2000, Text: "Halo"}, {Timestamp: 1000, Text: "Halo"}}, 261, false},
+		{LyricsLines{}, 261, false},
+	} {
+		if err := fixture.lines.Validate(fixture.duration); (err == nil) != fixture.valid {
+			t.Errorf("Validate(%v, %d) = %v, expected valid: %t", fixture.lines, fixture.duration, err, fixture.valid)
+		}
+	}
+}
+
+func TestTagsSyncedLyrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Beyonce - Halo.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lyrics := "[00:09.50]Halo\n[00:12.00]Remember those walls I built, ahí\n[01:01.80]Halo"
+	tags, err := LoadTags(path, true)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	tags.Set(ID3FrameSyncedLyrics, lyrics)
+	if err := tags.Save(); err != nil {
+		t.Fatalf("tags expected to be saved: %s", err.Error())
+	}
+	tags.Close()
+
+	tags, err = LoadTags(path, false)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	defer tags.Close()
+	if value := tags.Get(ID3FrameSyncedLyrics); value != lyrics {
+		t.Errorf("synced lyrics = %q, expected %q", value, lyrics)
+	}
+}
+
+type fixtureLyricsProvider struct {
+	name      string
+	lyrics    string
+	searches  *int
+	err       error
+	available bool
+}
+
+func (provider fixtureLyricsProvider) Name() string {
+	return provider.name
+}
+
+func (provider fixtureLyricsProvider) Available() bool {
+	return provider.available
+}
+
+func (provider fixtureLyricsProvider) Search(track *Track) (string, error) {
+	*provider.searches++
+	return provider.lyrics, provider.err
+}
+
+func TestSearchLyricsCache(t *testing.T) {
+	defer func(providers LyricsProviders) { ActiveLyricsProviders = providers }(ActiveLyricsProviders)
+	var (
+		searches int
+		cache    = LyricsCache{}
+		track    = Track{SpotifyID: "4JehYebiI9JE8sR8MisGVb"}
+	)
+
+	ActiveLyricsProviders = LyricsProviders{fixtureLyricsProvider{"first", "", &searches, nil, true}, fixtureLyricsProvider{"second", "", &searches, nil, true}}
+	if err := track.SearchLyrics(cache); err == nil || searches != 2 {
+		t.Errorf("lyrics expected not to be found by both providers, got %v after %d searches", err, searches)
+	}
+	if err := track.SearchLyrics(cache); err == nil || searches != 2 {
+		t.Errorf("not found lyrics expected to be cached, got %v after %d searches", err, searches)
+	}
+	cache[track.SpotifyID] = LyricsCacheEntry{Providers: cache[track.SpotifyID].Providers, Time: time.Now().Add(-LyricsNegativeCacheTTL * time.Hour)}
+	if err := track.SearchLyrics(cache); err == nil || searches != 4 {
+		t.Errorf("expired not found lyrics expected to be searched again, got %v after %d searches", err, searches)
+	}
+
+	ActiveLyricsProviders = LyricsProviders{fixtureLyricsProvider{"first", "", &searches, nil, true}, fixtureLyricsProvider{"third", "Halo", &searches, nil, true}}
+	if err := track.SearchLyrics(cache); err != nil || track.Lyrics != "Halo" || searches != 6 {
+		t.Errorf("lyrics expected to be found by a different providers chain, got %v after %d searches", err, searches)
+	}
+	if entry := cache[track.SpotifyID]; entry.Lyrics != "Halo" || entry.Provider != "third" {
+		t.Errorf("found lyrics expected to be cached, got %+v", entry)
+	}
+	track.Lyrics = ""
+	if err := track.SearchLyrics(cache); err != nil || track.Lyrics != "Halo" || searches != 6 {
+		t.Errorf("lyrics expected to be read from cache, got %v after %d searches", err, searches)
+	}
+
+	searches, cache = 0, LyricsCache{}
+	ActiveLyricsProviders = LyricsProviders{fixtureLyricsProvider{"failing", "", &searches, fmt.Errorf("unauthorized"), true},
+		fixtureLyricsProvider{"unavailable", "Halo", &searches, nil, false}, fixtureLyricsProvider{"second", "", &searches, nil, true}}
+	if err := track.SearchLyrics(cache); err == nil || searches != 2 {
+		t.Errorf("lyrics expected to be searched just by available providers, got %v after %d searches", err, searches)
+	}
+	if err := track.SearchLyrics(cache); err == nil || searches != 2 {
+		t.Errorf("not found lyrics expected to be cached despite failing providers, got %v after %d searches", err, searches)
+	}
+
+	searches, cache = 0, LyricsCache{track.SpotifyID: LyricsCacheEntry{Lyrics: "Halo (ASCII folded)", Provider: "third", Providers: "first,third", Time: time.Now()}}
+	ActiveLyricsProviders = LyricsProviders{fixtureLyricsProvider{"third", "Halo", &searches, nil, true}}
+	if err := track.SearchLyrics(cache); err != nil || track.Lyrics != "Halo" || searches != 1 || cache[track.SpotifyID].Version != LyricsCacheVersion {
+		t.Errorf("lyrics cached by a previous version expected to be searched again, got %v after %d searches", err, searches)
+	}
+
+	searches, cache = 0, LyricsCache{}
+	ActiveLyricsProviders = LyricsProviders{fixtureLyricsProvider{"failing", "", &searches, fmt.Errorf("unauthorized"), true}}
+	if err := track.SearchLyrics(cache); err == nil || len(cache) != 0 {
+		t.Errorf("lyrics searches failed by every provider expected not to be cached, got %v and %v", err, cache)
+	}
+}
+
+func TestGeniusLyricsProviderAvailable(t *testing.T) {
+	defer os.Setenv("GENIUS_TOKEN", os.Getenv("GENIUS_TOKEN"))
+	os.Setenv("GENIUS_TOKEN", "")
+	if len(GeniusAccessToken) != GeniusAccessTokenLength && (geniusLyricsProvider{}).Available() {
+		t.Errorf("Genius expected not to be available with a placeholder token")
+	}
+	os.Setenv("GENIUS_TOKEN", strings.Repeat("x", GeniusAccessTokenLength))
+	if !(geniusLyricsProvider{}).Available() {
+		t.Errorf("Genius expected to be available with a valid token")
+	}
+}
+
+func TestParseLyricsProviders(t *testing.T) {
+	providers, err := ParseLyricsProviders([]string{"lrclib", " Genius"})
+	if err != nil || providers.String() != "lrclib,genius" {
+		t.Errorf("ParseLyricsProviders() = %v, %v", providers, err)
+	}
+	if _, err := ParseLyricsProviders([]string{"genius", "azlyrics"}); err == nil {
+		t.Errorf("ParseLyricsProviders() expected to fail on unknown provider")
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for _, fixture := range []struct {
+		lyrics   string
+		expected string
+	}{
+		{"Remember those walls I built?\nWell, baby, they're tumbling down\nAnd they didn't even put up a fight", "eng"},
+		{"Non, je ne regrette rien\nNi le bien qu'on m'a fait\nNi le mal, tout ça m'est bien égal\nC'est payé, balayé, oublié", "fra"},
+		{"Despacito\nQuiero respirar tu cuello despacito\nDeja que te diga cosas al oído", "spa"},
+		{"Nel blu, dipinto di blu\nFelice di stare lassù\nE volavo, volavo felice più in alto del sole ed ancora più su", "ita"},
+		{"Du hast mich gefragt\nUnd ich hab nichts gesagt\nWillst du bis der Tod euch scheidet", "deu"},
+		{"Группа крови на рукаве\nМой порядковый номер на рукаве", "rus"},
+		{"Ти ж мене підманула\nТи ж мене підвела", "ukr"},
+		{"最後のキスは タバコの flavor がした", "jpn"},
+		{"月亮代表我的心", "zho"},
+		{"봄날\n보고 싶다 이렇게 말하니까 더 보고 싶다", "kor"},
+		{"Συννεφιασμένη Κυριακή", "ell"},
+		{"Halo", LyricsLanguageUndetermined},
+	} {
+		if language := DetectLanguage(fixture.lyrics); language != fixture.expected {
+			t.Errorf("DetectLanguage(%q) = %q, expected %q", fixture.lyrics, language, fixture.expected)
+		}
+	}
+}
+
+func TestTransliterateLyrics(t *testing.T) {
+	if transliteration := TransliterateLyrics("[Куплет 1]\nГруппа крови на рукаве\n\nМой порядковый номер"); transliteration != "[Kuplet 1]\nGruppa krovi na rukave\n\nMoi poriadkovyi nomer" {
+		t.Errorf("TransliterateLyrics() = %q", transliteration)
+	}
+}
+
+func TestTagsLyricsLanguage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Кино - Группа крови.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lyrics := "[Куплет 1]\nТёплое место, но улицы ждут\nОтпечатков наших ног"
+	tags, err := LoadTags(path, true)
+	if err != nil {
+		t.Fatalf("tags expected to be loaded: %s", err.Error())
+	}
+	tags.Set(ID3FrameTitle, "Группа крови")
+	tags.Set(ID3FrameLyrics, lyrics)
+	tags.Set(ID3FrameLyricsTransliteration, TransliterateLyrics(lyrics))
+	if err := tags.Save(); err != nil {
+		t.Fatalf("tags expected to be saved: %s", err.Error())
+	}
+	tags.Close()
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+	frames := tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))
+	if len(frames) != 2 {
+		t.Fatalf("lyrics expected to be stored along with their transliteration, got %d frames", len(frames))
+	}
+	for _, frame := range frames {
+		if lyricsFrame := frame.(id3v2.UnsynchronisedLyricsFrame); lyricsFrame.Language != "rus" {
+			t.Errorf("lyrics frame %q language = %q, expected \"rus\"", lyricsFrame.ContentDescriptor, lyricsFrame.Language)
+		}
+	}
+	if value := TagGetFrameLyrics(tag); value != lyrics {
+		t.Errorf("lyrics = %q, expected %q", value, lyrics)
+	}
+	if value := TagGetFrameLyricsTransliteration(tag); value != TransliterateLyrics(lyrics) {
+		t.Errorf("transliterated lyrics = %q, expected %q", value, TransliterateLyrics(lyrics))
+	}
+}
+
+func TestValidatePathTemplate(t *testing.T) {
+	for _, fixture := range []struct {
+		template string
+		valid    bool
+	}{
+		{PathTemplateDefault, true},
+		{"{albumartist}/{year} - {album}/{track:2} {title}", true},
+		{"", false},
+		{"/{artist}/{title}", false},
+		{"{artist}//{title}", false},
+		{"../{artist} - {title}", false},
+		{"{artist} - {name}", false},
+	} {
+		if err := ValidatePathTemplate(fixture.template); (err == nil) != fixture.valid {
+			t.Errorf("ValidatePathTemplate(%q) = %v, expected valid: %t", fixture.template, err, fixture.valid)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	var paths []string
+	for _, fixture := range []struct {
+		path      string
+		spotifyID string
+		url       string
+	}{
+		{"Beyonce/Halo.mp3", "4JehYebiI9JE8sR8MisGVb", "https://www.youtube.com/watch?v=bnVUHWCynig&list=RD"},
+		{"Beyonce/Single Ladies.mp3", "5R9a4t5t5O0IsznsrKPVro", "https://youtu.be/4m1EFMoRFvY"},
+		{"Untagged.mp3", "", ""},
+	} {
+		path := filepath.Join(dir, fixture.path)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+			t.Fatal(err)
+		}
+		tags, err := LoadTags(path, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags.Set(ID3FrameTitle, strings.TrimSuffix(filepath.Base(path), ".mp3"))
+		tags.Set(ID3FrameSpotifyID, fixture.spotifyID)
+		tags.Set(ID3FrameYouTubeURL, fixture.url)
+		tags.Set(ID3FrameDuration, "261")
+		if err := tags.Save(); err != nil {
+			t.Fatal(err)
+		}
+		tags.Close()
+		paths = append(paths, path)
+	}
+
+	index := NewIndex()
+	if scanned := index.Scan(paths, 2); scanned != 3 || index.Len() != 2 || len(index.Untracked) != 1 {
+		t.Fatalf("index expected to read 3 songs, tracking 2 of them, got %d read and %d tracked", scanned, index.Len())
+	}
+	record, ok := index.Get("4JehYebiI9JE8sR8MisGVb")
+	if !ok || record.Path != paths[0] || record.VideoID != "bnVUHWCynig" || record.Duration != 261 ||
+		record.Format != "mp3" || len(record.Hash) != 64 || record.Tags.Title != "Halo" {
+		t.Errorf("index record unexpected: %+v", record)
+	}
+	if record, _ := index.Get("5R9a4t5t5O0IsznsrKPVro"); record.VideoID != "4m1EFMoRFvY" {
+		t.Errorf("index record video ID = %q, expected \"4m1EFMoRFvY\"", record.VideoID)
+	}
+	if scanned := index.Scan(paths, 2); scanned != 0 {
+		t.Errorf("unmodified songs expected not to be read again, got %d read", scanned)
+	}
+
+	modTime := time.Now().Add(time.Hour)
+	os.Chtimes(paths[1], modTime, modTime)
+	os.Remove(paths[0])
+	if scanned := index.Scan(paths[1:], 2); scanned != 1 || index.Len() != 1 {
+		t.Errorf("index expected to read just the modified song and drop the vanished one, got %d read and %d tracked", scanned, index.Len())
+	}
+
+	touchedTime := modTime.Add(time.Hour)
+	os.Chtimes(paths[1], touchedTime, touchedTime)
+	index.Touch("5R9a4t5t5O0IsznsrKPVro", modTime.Add(-time.Minute), touchedTime)
+	if record, _ := index.Get("5R9a4t5t5O0IsznsrKPVro"); record.ModTime.Equal(touchedTime) {
+		t.Errorf("outdated index record expected not to be touched")
+	}
+	if touchedInfo, err := os.Stat(paths[1]); err == nil {
+		index.Touch("5R9a4t5t5O0IsznsrKPVro", modTime, touchedInfo.ModTime())
+	}
+	if scanned := index.Scan(paths[1:], 2); scanned != 0 {
+		t.Errorf("touched songs expected not to be read again, got %d read", scanned)
+	}
+
+	index.Fingerprint("5R9a4t5t5O0IsznsrKPVro", paths[1], "https://youtu.be/4m1EFMoRFvY", []uint32{1, 2, 3})
+	modTime = touchedTime.Add(time.Hour)
+	os.Chtimes(paths[1], modTime, modTime)
+	if scanned := index.Scan(paths[1:], 2); scanned != 1 {
+		t.Errorf("modified song expected to be read again, got %d read", scanned)
+	}
+	if record, _ := index.Get("5R9a4t5t5O0IsznsrKPVro"); len(record.Fingerprint) != 3 {
+		t.Errorf("index record expected to keep fingerprint of the same video, got %v", record.Fingerprint)
+	}
+	index.Relocate("5R9a4t5t5O0IsznsrKPVro", "Single Ladies.mp3")
+
+	indexPath := filepath.Join(dir, "index.gob")
+	if err := index.Dump(indexPath); err != nil {
+		t.Fatal(err)
+	}
+	if index, err = LoadIndex(indexPath); err != nil {
+		t.Fatalf("index expected to be loaded: %s", err.Error())
+	}
+	if path, ok := index.Path("5R9a4t5t5O0IsznsrKPVro"); !ok || path != "Single Ladies.mp3" || index.Version != IndexVersion {
+		t.Errorf("loaded index expected to keep relocated path, got %q", path)
+	}
+
+	if err := spttb_system.DumpGob(indexPath, TracksIndex{"4JehYebiI9JE8sR8MisGVb": "Beyonce - Halo.mp3"}); err != nil {
+		t.Fatal(err)
+	}
+	if index, err = LoadIndex(indexPath); err != nil {
+		t.Fatalf("legacy index expected to be loaded: %s", err.Error())
+	}
+	if path, ok := index.Path("4JehYebiI9JE8sR8MisGVb"); !ok || path != "Beyonce - Halo.mp3" {
+		t.Errorf("legacy index expected to be converted, got %q", path)
+	}
+}
+
+func TestParseAuditFixes(t *testing.T) {
+	if fixes, err := ParseAuditFixes([]string{"junk", " Broken-Symlink ", ""}); err != nil ||
+		len(fixes) != 2 || fixes[0] != AuditJunk || fixes[1] != AuditBrokenSymlink {
+		t.Errorf("audit fixes unexpected: %v (%v)", fixes, err)
+	}
+	if fixes, err := ParseAuditFixes([]string{"all"}); err != nil || len(fixes) != len(AuditFixableCategories) {
+		t.Errorf("audit fixes expected to include every fixable category, got %v (%v)", fixes, err)
+	}
+	if fixes, err := ParseAuditFixes([]string{""}); err != nil || len(fixes) != 0 {
+		t.Errorf("audit fixes expected to be empty, got %v (%v)", fixes, err)
+	}
+	for _, name := range []string{"duplicate-isrc", "unknown"} {
+		if _, err := ParseAuditFixes([]string{name}); err == nil {
+			t.Errorf("audit fix %q expected to be refused", name)
+		}
+	}
+}
+
+func TestAuditReport(t *testing.T) {
+	report := NewAuditReport("/music")
+	report.Append(AuditNoSpotifyID, "Untagged.mp3", "")
+	report.Issues[report.Append(AuditJunk, ".Halo.part", "")].Fixed = true
+	report.AppendDuplicates(AuditDuplicateSpotifyID, map[string][]string{
+		"4JehYebiI9JE8sR8MisGVb": {"b/Halo.mp3", "a/Halo.mp3"},
+		"5R9a4t5t5O0IsznsrKPVro": {"Single Ladies.mp3"},
+	})
+	if len(report.Issues) != 4 || report.Summary[AuditDuplicateSpotifyID] != 2 || report.Summary[AuditMissingGenre] != 0 {
+		t.Fatalf("audit report unexpected: %+v", report)
+	}
+	if issue := report.Issues[2]; issue.Path != "a/Halo.mp3" || issue.Detail != "4JehYebiI9JE8sR8MisGVb shared with b/Halo.mp3" {
+		t.Errorf("duplicate issue unexpected: %+v", issue)
+	}
+	if report.CountFixed(AuditJunk) != 1 || report.CountFixed(AuditNoSpotifyID) != 0 {
+		t.Errorf("audit report fixed issues count unexpected")
+	}
+
+	reportJSON, err := report.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded AuditReport
+	if err := json.Unmarshal(reportJSON, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Folder != "/music" || len(decoded.Issues) != 4 || decoded.Summary[AuditJunk] != 1 || !decoded.Issues[1].Fixed {
+		t.Errorf("decoded audit report unexpected: %+v", decoded)
+	}
+}
+
+func TestOpenUntaggedTrack(t *testing.T) {
+	dir, err := ioutil.TempDir("", "untagged")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "07 - Beyonce - Halo.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if track := OpenUntaggedTrack(path); track.Artist != "Beyonce" || track.Title != "Halo" || track.FilenameExt != ".mp3" {
+		t.Errorf("untagged track unexpected: %+v", track)
+	}
+
+	tags, err := LoadTags(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags.Set(ID3FrameTitle, "Halo (Live)")
+	tags.Set(ID3FrameISRC, "USSM10804556")
+	if err := tags.Save(); err != nil {
+		t.Fatal(err)
+	}
+	tags.Close()
+	if track := OpenUntaggedTrack(path); track.Artist != "Beyonce" || track.Title != "Halo (Live)" || track.ISRC != "USSM10804556" {
+		t.Errorf("partially tagged track unexpected: %+v", track)
+	} else if queries := track.ImportQueries(); len(queries) != 3 || queries[0] != "isrc:USSM10804556" ||
+		queries[1] != "track:\"Halo\" artist:\"Beyonce\"" || queries[2] != "beyonce halo" {
+		t.Errorf("import queries unexpected: %q", queries)
+	}
+}
+
+func TestImportMatch(t *testing.T) {
+	spotifyTrack := func(name string, artist string, duration int, isrc string) spotify.FullTrack {
+		return spotify.FullTrack{
+			SimpleTrack: spotify.SimpleTrack{
+				Name:     name,
+				Artists:  []spotify.SimpleArtist{{Name: artist}},
+				Duration: duration * 1000,
+			},
+			ExternalIDs: map[string]string{"isrc": isrc},
+		}
+	}
+	var (
+		halo      = spotifyTrack("Halo", "Beyoncé", 261, "USSM10804556")
+		haloLive  = spotifyTrack("Halo - Live", "Beyoncé", 290, "USSM10900001")
+		haloCover = spotifyTrack("Halo", "Some Karaoke Band", 262, "GBXXX1200001")
+		local     = Track{Title: "Halo", Artist: "Beyonce", Duration: 260}
+	)
+
+	if match, confidence := local.ImportMatch([]spotify.FullTrack{haloCover, haloLive, halo}); match.Name != "Halo" ||
+		match.Artists[0].Name != "Beyoncé" || confidence < ImportConfidenceThreshold {
+		t.Errorf("import match unexpected: %s by %s (%.2f)", match.Name, match.Artists[0].Name, confidence)
+	}
+	if confidence := local.ImportConfidence(haloCover); confidence >= ImportConfidenceThreshold {
+		t.Errorf("cover confidence expected to require confirmation, got %.2f", confidence)
+	}
+	if confidence := (Track{Title: "Unrelated", Artist: "Nobody", ISRC: "USSM10804556"}).ImportConfidence(halo); confidence != 1 {
+		t.Errorf("ISRC match confidence expected to be 1, got %.2f", confidence)
+	}
+	if _, confidence := local.ImportMatch(nil); confidence != 0 {
+		t.Errorf("no results match confidence expected to be 0, got %.2f", confidence)
+	}
+	haloNoArtists := halo
+	haloNoArtists.SimpleTrack.Artists = nil
+	if match, confidence := (Track{ISRC: "USSM10804556"}).ImportMatch([]spotify.FullTrack{haloNoArtists}); confidence != 0 || len(match.Artists) != 0 {
+		t.Errorf("results without artists expected to be ignored, got %.2f confidence", confidence)
+	}
+}
+
+func TestParseFix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Beyonce, Jay-Z - Drunk in Love.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range []struct {
+		entry    string
+		expected Fix
+	}{
+		{path, Fix{Target: path, Path: path, Mode: FixModeRedownload}},
+		{path + ",https://youtu.be/p1JPKLa-Ofc", Fix{Target: path, Path: path, URL: "https://youtu.be/p1JPKLa-Ofc", Mode: FixModeRedownload}},
+		{"Metadata," + path, Fix{Target: path, Path: path, Mode: FixModeMetadata}},
+		{"spotify:track:6jG2YzhxptolDzLHTGLt7S,metadata", Fix{Target: "spotify:track:6jG2YzhxptolDzLHTGLt7S", SpotifyID: "6jG2YzhxptolDzLHTGLt7S", Mode: FixModeMetadata}},
+		{"https://open.spotify.com/track/6jG2YzhxptolDzLHTGLt7S?si=abc, https://www.youtube.com/watch?v=p1JPKLa-Ofc",
+			Fix{Target: "https://open.spotify.com/track/6jG2YzhxptolDzLHTGLt7S?si=abc", SpotifyID: "6jG2YzhxptolDzLHTGLt7S",
+				URL: "https://www.youtube.com/watch?v=p1JPKLa-Ofc", Mode: FixModeRedownload}},
+	} {
+		fix, err := ParseFix(strings.Split(fixture.entry, ","))
+		if err != nil {
+			t.Errorf("fix %q expected to be parsed: %s", fixture.entry, err.Error())
+		} else if fix.Target != fixture.expected.Target || fix.Path != fixture.expected.Path || fix.SpotifyID != fixture.expected.SpotifyID ||
+			fix.URL != fixture.expected.URL || fix.Mode != fixture.expected.Mode {
+			t.Errorf("fix %q = %+v, expected %+v", fixture.entry, fix, fixture.expected)
+		}
+	}
+
+	for _, entry := range []string{"", "metadata", filepath.Join(dir, "missing.mp3"), path + ",https://youtu.be/p1JPKLa-Ofc,metadata"} {
+		if _, err := ParseFix(strings.Split(entry, ",")); err == nil {
+			t.Errorf("fix %q expected to be refused", entry)
+		}
+	}
+
+	list := filepath.Join(dir, "fixes.csv")
+	if err := ioutil.WriteFile(list, []byte("# songs to fix\n"+
+		"\""+path+"\",https://youtu.be/p1JPKLa-Ofc\n\n"+
+		"spotify:track:6jG2YzhxptolDzLHTGLt7S, metadata\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if fixes, err := ParseFixFile(list); err != nil || len(fixes) != 2 || fixes[0].Path != path ||
+		fixes[0].URL != "https://youtu.be/p1JPKLa-Ofc" || fixes[1].Mode != FixModeMetadata {
+		t.Errorf("fixes file unexpected: %+v (%v)", fixes, err)
+	}
+	if err := ioutil.WriteFile(list, []byte(path+"\nunknown.mp3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseFixFile(list); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("fixes file expected to be refused at its second line, got %v", err)
+	}
+}
+
+func TestFixChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Halo.mp3")
+	if err := ioutil.WriteFile(path, make([]byte, 64), 0644); err != nil {
+		t.Fatal(err)
+	}
+	setTags := func(path string, frames map[int]string) {
+		tags, err := LoadTags(path, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for frame, value := range frames {
+			tags.Set(frame, value)
+		}
+		if err := tags.Save(); err != nil {
+			t.Fatal(err)
+		}
+		tags.Close()
+	}
+	setTags(path, map[int]string{ID3FrameTitle: "Halo", ID3FrameGenre: "Pop", ID3FrameYouTubeURL: "https://youtu.be/bnVUHWCynig"})
+
+	fix := Fix{Target: path}
+	if changes := fix.Changes(path); len(changes) != 1 || !strings.HasPrefix(changes[0], "downloaded") {
+		t.Errorf("changes of a song not existing before expected to be its download, got %q", changes)
+	}
+	if err := fix.Snapshot(path); err != nil {
+		t.Fatal(err)
+	}
+	if changes := fix.Changes(path); len(changes) != 0 {
+		t.Errorf("untouched song expected not to have changes, got %q", changes)
+	}
+
+	setTags(path, map[int]string{ID3FrameTitle: "Halo (Live)", ID3FrameGenre: "", ID3FrameAlbum: "I Am... Sasha Fierce",
+		ID3FrameYouTubeURL: "https://youtu.be/4JehYebiI9J"})
+	pathMoved := filepath.Join(dir, "Beyonce", "Halo.mp3")
+	os.MkdirAll(filepath.Dir(pathMoved), 0755)
+	os.Rename(path, pathMoved)
+	expected := []string{"moved from " + path + " to " + pathMoved, "title changed", "album added", "genre removed",
+		"audio replaced from https://youtu.be/4JehYebiI9J"}
+	if changes := fix.Changes(pathMoved); strings.Join(changes, "|") != strings.Join(expected, "|") {
+		t.Errorf("fixed song changes = %q, expected %q", changes, expected)
+	}
+}
+
+func TestArtworkCache(t *testing.T) {
+	var picture bytes.Buffer
+	if err := png.Encode(&picture, image.NewRGBA(image.Rect(0, 0, 64, 64))); err != nil {
+		t.Fatal(err)
+	}
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(picture.Bytes())
+	}))
+	defer server.Close()
+
+	folder, err := ioutil.TempDir("", "artworks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	cache := NewArtworkCache(filepath.Join(folder, "cache"), 32)
+	if cache.Has(server.URL) {
+		t.Errorf("Has() expected to be false before fetching")
+	}
+	for i := 0; i < 2; i++ {
+		artwork, mimeType, err := cache.Fetch(server.URL)
+		if err != nil {
+			t.Fatalf("Fetch() unexpected error: %s", err.Error())
+		}
+		if mimeType != ArtworkMIMEPNG {
+			t.Errorf("Fetch() MIME type = %q, expected %q", mimeType, ArtworkMIMEPNG)
+		}
+		if artworkImage, _, err := image.Decode(bytes.NewReader(artwork)); err != nil || artworkImage.Bounds().Dx() != 32 {
+			t.Errorf("Fetch() expected artwork to be resized to 32 pixels")
+		}
+	}
+	if requests != 1 {
+		t.Errorf("Fetch() performed %d requests, expected 1", requests)
+	}
+	if !cache.Has(server.URL) {
+		t.Errorf("Has() expected to be true after fetching")
+	}
+
+	album := filepath.Join(folder, "Artist", "Album")
+	os.MkdirAll(album, 0755)
+	if written, err := cache.WriteCover(server.URL, album); err != nil || written != len(ArtworkCoverFilenames) {
+		t.Errorf("WriteCover() = %d, %v, expected %d covers", written, err, len(ArtworkCoverFilenames))
+	}
+	for _, filename := range ArtworkCoverFilenames {
+		cover, err := ioutil.ReadFile(filepath.Join(album, filename))
+		if err != nil {
+			t.Fatalf("Cover %s expected to be written", filename)
+		}
+		if mimeType, _ := ArtworkMIME(cover); mimeType != ArtworkMIMEJPEG {
+			t.Errorf("Cover %s MIME type = %q, expected %q", filename, mimeType, ArtworkMIMEJPEG)
+		}
+	}
+	if written, _ := cache.WriteCover(server.URL, album); written != 0 {
+		t.Errorf("WriteCover() expected to leave existing covers untouched, wrote %d", written)
+	}
+
+	if _, err := ArtworkMIME([]byte("<html></html>")); err == nil {
+		t.Errorf("ArtworkMIME() expected to reject non-image content")
+	}
+}
+
+func TestFilenameWorkspace(t *testing.T) {
+	defer func(workspace string) { ActiveWorkspace = workspace }(ActiveWorkspace)
+	track := Track{Artist: "Beyoncé", Title: "Halo", Song: "Halo"}
+	track.Filename, track.FilenameTemp = parseFilename(track)
+	track.FilenameExt = ".mp3"
+
+	ActiveWorkspace = filepath.Join("music", ".spotitube", "run-1")
+	for filename, expected := range map[string]string{
+		track.FilenameTemporary():    filepath.Join(ActiveWorkspace, track.FilenameTemp+".mp3"),
+		track.FilenameStream():       filepath.Join(ActiveWorkspace, track.FilenameTemp+".stream"),
+		track.FilenameStreamOrigin(): filepath.Join(ActiveWorkspace, track.FilenameTemp+".stream.origin"),
+		track.FilenameFinal():        track.Filename + ".mp3",
+	} {
+		if filename != expected {
+			t.Errorf("Filename = %q, expected %q", filename, expected)
+		}
+	}
+}
+
+func TestParseSpotifyTrackSearchPattern(t *testing.T) {
+	defer func(template string) { ActivePathTemplate = template }(ActivePathTemplate)
+	spotifyTrack := spotify.FullTrack{
+		SimpleTrack: spotify.SimpleTrack{Name: "Get Lucky (feat. Pharrell Williams)", TrackNumber: 8, DiscNumber: 1, ID: "69kOkLUCkxIZYexIgSG8rq",
+			Artists: []spotify.SimpleArtist{{Name: "Daft Punk"}, {Name: "Pharrell Williams"}, {Name: "Nile Rodgers"}}},
+		Album: spotify.SimpleAlbum{Name: "Random Access Memories"},
+	}
+	for _, template := range []string{PathTemplateDefault, "{albumartist}/{year} - {album}/{track:2} {title}", "{spotifyid}"} {
+		ActivePathTemplate = template
+		track := ParseSpotifyTrack(spotifyTrack, spotify.FullAlbum{ReleaseDate: "2013-05-17"})
+		if expected := "daft punk get lucky ft pharrell williams nile rodgers"; track.SearchPattern != expected {
+			t.Errorf("ParseSpotifyTrack() search pattern with %q template = %q, expected %q", template, track.SearchPattern, expected)
+		}
+	}
+}
+
+func TestFilenamePlaylist(t *testing.T) {
+	for _, fixture := range []struct {
+		filename string
+		expected string
+	}{
+		{"Beyonce - Halo", "Beyonce - Halo.mp3"},
+		{"Daft Punk/Random Access Memories/01 Give Life Back to Music", "Daft Punk - Random Access Memories - 01 Give Life Back to Music.mp3"},
+		{"Daft Punk/Homework/01 Daftendirekt", "Daft Punk - Homework - 01 Daftendirekt.mp3"},
+	} {
+		if filename := (Track{Filename: fixture.filename, FilenameExt: ".mp3"}).FilenamePlaylist(); filename != fixture.expected {
+			t.Errorf("FilenamePlaylist(%q) = %q, expected %q", fixture.filename, filename, fixture.expected)
+		}
+	}
+}
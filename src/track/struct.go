@@ -1,31 +1,52 @@
 package track
 
 import (
+	"sync"
 	"time"
+	"unicode"
+
+	"github.com/bogem/id3v2"
 )
 
 // Track : struct containing all the informations about a track
 type Track struct {
-	Title         string
-	Song          string
-	Artist        string
-	Album         string
-	Year          string
-	Featurings    []string
-	Genre         string
-	TrackNumber   int
-	TrackTotals   int
-	Duration      int
-	SongType      int
-	Image         string
-	URL           string
-	SpotifyID     string
-	Filename      string
-	FilenameTemp  string
-	FilenameExt   string
-	SearchPattern string
-	Lyrics        string
-	Local         bool
+	Title                 string
+	Song                  string
+	Artist                string
+	Album                 string
+	AlbumArtist           string
+	Year                  string
+	Featurings            []string
+	Genre                 string
+	TrackNumber           int
+	TrackTotals           int
+	DiscNumber            int
+	Duration              int
+	TrimFrom              float64
+	TrimTo                float64
+	SongType              int
+	Versions              []string
+	VersionTags           []string
+	Image                 string
+	Preview               string
+	URL                   string
+	SpotifyID             string
+	ISRC                  string
+	Filename              string
+	FilenameTemp          string
+	FilenameExt           string
+	SearchPattern         string
+	Lyrics                string
+	SyncedLyrics          string
+	LyricsTransliteration string
+	Verification          string
+	Fingerprint           []uint32
+	Quality               string
+	TrackGain             string
+	TrackPeak             string
+	AlbumGain             string
+	AlbumPeak             string
+	Local                 bool
 }
 
 // Tracks : Track array
@@ -37,5 +58,211 @@ type TracksDump struct {
 	Time   time.Time
 }
 
-// TracksIndex : Tracks index keeping ID - filename mapping
+// TracksIndex : legacy Tracks index keeping ID - filename mapping, superseded by Index
 type TracksIndex map[string]string
+
+// Index : versioned local songs index, keeping records by Spotify ID, along with the ones of songs
+// without it by path, safe for concurrent use
+type Index struct {
+	Version   int
+	Records   map[string]IndexRecord
+	Untracked map[string]IndexRecord
+	mutex     sync.RWMutex
+}
+
+// IndexRecord : local song informations, as kept by Index
+type IndexRecord struct {
+	Path        string
+	Size        int64
+	ModTime     time.Time
+	Duration    int
+	Format      string
+	VideoID     string
+	Hash        string
+	Fingerprint []uint32
+	Tags        IndexTags
+}
+
+// IndexTags : local song tags summary, as kept by Index
+type IndexTags struct {
+	Title   string
+	Artist  string
+	Album   string
+	Year    string
+	Quality string
+	Lyrics  bool
+}
+
+// AuditReport : library audit outcome, as it gets exported into JSON
+type AuditReport struct {
+	Folder  string         `json:"folder"`
+	Time    time.Time      `json:"time"`
+	Songs   int            `json:"songs"`
+	Summary map[string]int `json:"summary"`
+	Issues  []AuditIssue   `json:"issues"`
+}
+
+// AuditIssue : single problem encountered while auditing library
+type AuditIssue struct {
+	Category string `json:"category"`
+	Path     string `json:"path"`
+	Detail   string `json:"detail,omitempty"`
+	Fixed    bool   `json:"fixed"`
+}
+
+// ArtworkCache : persistent artworks cache, keyed by their URL, eventually resized to fit into Size pixels
+type ArtworkCache struct {
+	Path  string
+	Size  int
+	mutex sync.Mutex
+}
+
+// Fix : single song fix request, targeting either a local song path or a Spotify track, along with
+// the state of the local song before getting fixed
+type Fix struct {
+	Target    string
+	SpotifyID string
+	Path      string
+	URL       string
+	Mode      string
+	Frames    map[int]string
+}
+
+// VersionRule : rule describing how a title version gets recognised and how it affects matching
+type VersionRule struct {
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Policy  string   `json:"policy"`
+}
+
+// VersionRules : VersionRule array
+type VersionRules []VersionRule
+
+// Tagger : interface abstracting songs tags reading and writing, whatever their format
+type Tagger interface {
+	GetFrame(frame int) string
+	HasFrame(frame int) bool
+	SetFrame(frame int, value string)
+	SetArtwork(mimeType string, picture []byte)
+	DeleteFrames()
+	Save() error
+	Close() error
+}
+
+type id3Tagger struct {
+	tag *id3v2.Tag
+}
+
+type ffmpegTagger struct {
+	path         string
+	format       string
+	frames       map[string]string
+	comments     map[string]string
+	picture      []byte
+	pictureMime  string
+	pictureSet   bool
+	pictureFound bool
+}
+
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType   string            `json:"codec_type"`
+		Tags        map[string]string `json:"tags"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
+	} `json:"streams"`
+	Format struct {
+		Tags map[string]string `json:"tags"`
+	} `json:"format"`
+}
+
+// Tags : struct containing every frame of a song, loaded at once and written at once
+type Tags struct {
+	Path        string
+	Frames      map[int]string
+	Artwork     bool
+	Partial     bool
+	Legacy      []int
+	tagger      Tagger
+	changed     map[int]bool
+	cleared     bool
+	picture     []byte
+	pictureMime string
+}
+
+// LyricsProvider : lyrics source, searching Track lyrics, returning empty lyrics and nil error
+// whenever they just cannot be found
+type LyricsProvider interface {
+	Name() string
+	Available() bool
+	Search(track *Track) (string, error)
+}
+
+// LyricsProviders : LyricsProvider array, consulted in order
+type LyricsProviders []LyricsProvider
+
+type lyricsLanguage struct {
+	Language  string
+	Stopwords []string
+}
+
+type lyricsScript struct {
+	Script   *unicode.RangeTable
+	Language string
+}
+
+type geniusLyricsProvider struct{}
+
+type ovhLyricsProvider struct{}
+
+type lrclibLyricsProvider struct{}
+
+type geniusSearchResponse struct {
+	Response struct {
+		Hits []struct {
+			Result struct {
+				Title         string `json:"title"`
+				URL           string `json:"url"`
+				PrimaryArtist struct {
+					Name string `json:"name"`
+				} `json:"primary_artist"`
+			} `json:"result"`
+		} `json:"hits"`
+	} `json:"response"`
+}
+
+// LyricsCacheEntry : lyrics search outcome, either positive or negative, as cached
+type LyricsCacheEntry struct {
+	Lyrics    string
+	Provider  string
+	Providers string
+	Time      time.Time
+	Version   int
+}
+
+// LyricsCache : lyrics search outcomes cache, keyed by Spotify ID
+type LyricsCache map[string]LyricsCacheEntry
+
+// LyricsLine : synced lyrics line, with its timestamp in milliseconds
+type LyricsLine struct {
+	Timestamp int
+	Text      string
+}
+
+// LyricsLines : synced lyrics lines array
+type LyricsLines []LyricsLine
+
+type syncedLyricsFrame struct {
+	Language string
+	Lines    LyricsLines
+}
+
+type urlFrame struct {
+	URL string
+}
+
+type userURLFrame struct {
+	Description string
+	URL         string
+}
@@ -1,9 +1,167 @@
 package track
 
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	spttb_system "system"
+)
+
 var (
 	// SongTypes : array containing every song variant identifier
 	SongTypes = []int{SongTypeLive, SongTypeCover, SongTypeRemix,
 		SongTypeAcoustic, SongTypeKaraoke, SongTypeParody}
-	// JunkSuffixes : array containing every file suffix considered junk
-	JunkSuffixes = []string{".ytdl", ".webm", ".opus", ".part", ".jpg", ".tmp", "-id3v2"}
+	// SongTypeVersions : map binding every song variant identifier to its version rule name
+	SongTypeVersions = map[int]string{
+		SongTypeLive:     VersionLive,
+		SongTypeCover:    VersionCover,
+		SongTypeRemix:    VersionRemix,
+		SongTypeAcoustic: VersionAcoustic,
+		SongTypeKaraoke:  VersionKaraoke,
+		SongTypeParody:   VersionParody,
+		SongTypeReverse:  VersionReverse,
+	}
+	// ResumableSuffixes : array containing every intermediate file suffix worth being resumed from stale workspaces
+	ResumableSuffixes = []string{".stream", ".stream.origin"}
+	// ActiveWorkspace : per-run workspace folder intermediate files get written into (current folder, if empty)
+	ActiveWorkspace = ""
+	// ActiveExtension : extension newly synchronized songs get encoded with
+	ActiveExtension = spttb_system.SongExtension
+	// DefaultVersionRules : version rules used whenever no custom rules file is provided
+	DefaultVersionRules = VersionRules{
+		{Name: VersionLive, Aliases: []string{"live", "@", "perform", "performance", "tour", "concert"}, Policy: VersionPolicyStrict},
+		{Name: VersionCover, Aliases: []string{"cover", "vs", "amateur"}, Policy: VersionPolicyStrict},
+		{Name: VersionRemix, Aliases: []string{"remix", "rmx", "bootleg", "vip mix"}, Policy: VersionPolicyStrict},
+		{Name: VersionAcoustic, Aliases: []string{"acoustic", "unplugged"}, Policy: VersionPolicyStrict},
+		{Name: VersionKaraoke, Aliases: []string{"karaoke", "instrumental"}, Policy: VersionPolicyStrict},
+		{Name: VersionParody, Aliases: []string{"parody"}, Policy: VersionPolicyStrict},
+		{Name: VersionReverse, Aliases: []string{"reverse", "reversed"}, Policy: VersionPolicyStrict},
+		{Name: VersionExtended, Aliases: []string{"extended", "extended mix", "extended version"}, Policy: VersionPolicyStrict},
+		{Name: VersionSpedUp, Aliases: []string{"sped up", "speed up", "spedup"}, Policy: VersionPolicyStrict},
+		{Name: VersionSlowed, Aliases: []string{"slowed", "slowed reverb", "reverb"}, Policy: VersionPolicyStrict},
+		{Name: VersionNightcore, Aliases: []string{"nightcore"}, Policy: VersionPolicyStrict},
+		{Name: Version8D, Aliases: []string{"8d", "8d audio"}, Policy: VersionPolicyStrict},
+		{Name: VersionDemo, Aliases: []string{"demo"}, Policy: VersionPolicyStrict},
+		{Name: VersionRadioEdit, Aliases: []string{"radio edit", "radio version", "edit"}, Policy: VersionPolicyIgnore},
+		{Name: VersionRemaster, Aliases: []string{"remaster", "remastered", "remastering"}, Policy: VersionPolicyIgnore},
+		{Name: VersionMono, Aliases: []string{"mono", "mono version", "stereo"}, Policy: VersionPolicyIgnore},
+	}
+	// ActiveVersionRules : version rules currently used for parsing and matching
+	ActiveVersionRules = DefaultVersionRules
+	// lyricsScripts : non-Latin scripts, bound to the language lyrics written with them are detected as
+	lyricsScripts = []lyricsScript{
+		{unicode.Hangul, "kor"},
+		{unicode.Han, "zho"},
+		{unicode.Cyrillic, "rus"},
+		{unicode.Greek, "ell"},
+		{unicode.Arabic, "ara"},
+		{unicode.Hebrew, "heb"},
+		{unicode.Thai, "tha"},
+		{unicode.Devanagari, "hin"},
+	}
+	// lyricsLanguages : Latin script languages, along with their most common lyrics stopwords
+	lyricsLanguages = []lyricsLanguage{
+		{"eng", []string{"the", "and", "you", "i", "to", "my", "me", "it", "is", "that", "your", "don't", "with", "what", "be", "we", "all", "just", "they", "a", "of", "in", "on", "but"}},
+		{"fra", []string{"le", "les", "et", "je", "tu", "des", "une", "est", "pas", "mon", "dans", "pour", "qui", "nous", "vous", "moi", "toi", "c'est"}},
+		{"spa", []string{"el", "los", "las", "y", "yo", "mi", "es", "por", "con", "una", "te", "amor", "tú", "qué", "como", "pero", "está", "quiero", "que", "al", "del", "nada"}},
+		{"ita", []string{"il", "che", "di", "non", "io", "sono", "per", "ti", "mi", "è", "amore", "ho", "sei", "della", "nel", "questo", "anche"}},
+		{"deu", []string{"der", "die", "das", "und", "ich", "du", "nicht", "ist", "ein", "eine", "mit", "mich", "dich", "wir", "zu", "auf", "sich"}},
+		{"por", []string{"os", "eu", "você", "não", "um", "uma", "meu", "minha", "com", "é", "do", "da", "em", "isso", "quando", "mais", "coração"}},
+		{"nld", []string{"het", "een", "ik", "je", "niet", "van", "dat", "mijn", "met", "op", "wat", "zijn", "jij", "maar", "voor", "wij"}},
+	}
+	// ActivePathTemplate : path template currently used for songs filenames
+	ActivePathTemplate = PathTemplateDefault
+	// PathTemplateFields : fields path templates can be composed of
+	PathTemplateFields = []string{"title", "song", "artist", "album", "albumartist", "year", "genre",
+		"featurings", "track", "tracktotals", "disc", "spotifyid", "isrc"}
+	// pathReservedNames : names FAT/exFAT and Windows do not allow for files nor folders
+	pathReservedNames = []string{"CON", "PRN", "AUX", "NUL",
+		"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+		"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
+	// AuditCategories : library audit categories, in reporting order
+	AuditCategories = []string{AuditNoSpotifyID, AuditMissingArtwork, AuditMissingLyrics, AuditMissingGenre,
+		AuditDurationMismatch, AuditDuplicateSpotifyID, AuditDuplicateISRC, AuditBrokenSymlink, AuditJunk, AuditUndecodable}
+	// AuditFixableCategories : library audit categories whose issues can be automatically fixed
+	AuditFixableCategories = []string{AuditMissingArtwork, AuditMissingLyrics, AuditBrokenSymlink, AuditJunk}
+	// ArtworkCoverFilenames : filenames album artwork gets written into songs folders with
+	ArtworkCoverFilenames = []string{"cover.jpg", "folder.jpg"}
+	// FixModes : modes songs can be fixed with
+	FixModes = []string{FixModeRedownload, FixModeMetadata}
+	// DefaultLyricsProviders : lyrics providers consulted by default, in order
+	DefaultLyricsProviders = LyricsProviders{geniusLyricsProvider{}, ovhLyricsProvider{}, lrclibLyricsProvider{}}
+	// ActiveLyricsProviders : lyrics providers currently consulted, in order
+	ActiveLyricsProviders = DefaultLyricsProviders
+
+	pathTemplatePattern    = regexp.MustCompile(`\{([a-z]+)(?::(\d+))?\}`)
+	lyricsTimestampPattern = regexp.MustCompile(`^\[(\d+):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lyricsTagPattern       = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+	spotifyTrackPattern    = regexp.MustCompile(`^(?:spotify:track:|https?://open\.spotify\.com/(?:intl-[a-z-]+/)?track/)([0-9A-Za-z]{22})(?:[?#].*)?$`)
+	trackNumberPattern     = regexp.MustCompile(`^\d{1,3}\s*(?:[-.)]\s*|\s)`)
+	versionGroupPattern    = regexp.MustCompile(`\s*[(\[{]([^()\[\]{}]+)[)\]}]`)
+	// tagFrameDescriptions : map binding custom frames to the description they get stored with
+	tagFrameDescriptions = map[int]string{
+		ID3FrameSong:         "song",
+		ID3FrameFeaturings:   "featurings",
+		ID3FrameTrackTotals:  "trackTotals",
+		ID3FrameArtworkURL:   "artwork",
+		ID3FrameYouTubeURL:   "youtube",
+		ID3FrameDuration:     "duration",
+		ID3FrameSpotifyID:    "spotifyid",
+		ID3FrameVerification: "verification",
+		ID3FrameQuality:      "quality",
+		ID3FrameTrackGain:    ReplayGainTrackGain,
+		ID3FrameTrackPeak:    ReplayGainTrackPeak,
+		ID3FrameAlbumGain:    ReplayGainAlbumGain,
+		ID3FrameAlbumPeak:    ReplayGainAlbumPeak,
+		ID3FrameISRC:         "isrc",
+	}
+	// tagVorbisKeys : map binding standard frames to their Vorbis comment key
+	tagVorbisKeys = map[int]string{
+		ID3FrameTitle:                 "TITLE",
+		ID3FrameArtist:                "ARTIST",
+		ID3FrameAlbum:                 "ALBUM",
+		ID3FrameGenre:                 "GENRE",
+		ID3FrameYear:                  "DATE",
+		ID3FrameTrackNumber:           "TRACKNUMBER",
+		ID3FrameTrackTotals:           "TRACKTOTAL",
+		ID3FrameLyrics:                "LYRICS",
+		ID3FrameAlbumArtist:           "ALBUMARTIST",
+		ID3FrameDiscNumber:            "DISCNUMBER",
+		ID3FrameISRC:                  "ISRC",
+		ID3FrameSyncedLyrics:          "SYNCEDLYRICS",
+		ID3FrameLyricsTransliteration: "LYRICS_TRANSLITERATION",
+	}
+	// tagMP4Keys : map binding standard frames to their MP4 atom ffmpeg key
+	tagMP4Keys = map[int]string{
+		ID3FrameTitle:       "title",
+		ID3FrameArtist:      "artist",
+		ID3FrameAlbum:       "album",
+		ID3FrameGenre:       "genre",
+		ID3FrameYear:        "date",
+		ID3FrameTrackNumber: "track",
+		ID3FrameLyrics:      "lyrics",
+		ID3FrameAlbumArtist: "album_artist",
+		ID3FrameDiscNumber:  "disc",
+	}
+	// tagMP4PackedKeys : map binding standard frames with no MP4 atom to the description they get packed into comment with
+	tagMP4PackedKeys = map[int]string{
+		ID3FrameSyncedLyrics:          "syncedlyrics",
+		ID3FrameLyricsTransliteration: "transliteration",
+	}
+	// mp4CommentEscaper : replacer escaping packed comment values, so that multiline ones take a single line
+	mp4CommentEscaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+	// mp4CommentUnescaper : replacer reverting mp4CommentEscaper escaping
+	mp4CommentUnescaper = strings.NewReplacer("\\\\", "\\", "\\n", "\n", "\\r", "\r")
+	// TagFrames : array containing every textual frame identifier, loaded at once by Tags
+	TagFrames = []int{ID3FrameTitle, ID3FrameSong, ID3FrameArtist, ID3FrameAlbum, ID3FrameGenre, ID3FrameYear,
+		ID3FrameFeaturings, ID3FrameTrackNumber, ID3FrameTrackTotals, ID3FrameArtworkURL, ID3FrameLyrics,
+		ID3FrameYouTubeURL, ID3FrameDuration, ID3FrameSpotifyID, ID3FrameVerification, ID3FrameQuality,
+		ID3FrameTrackGain, ID3FrameTrackPeak, ID3FrameAlbumGain, ID3FrameAlbumPeak,
+		ID3FrameAlbumArtist, ID3FrameDiscNumber, ID3FrameISRC, ID3FrameSyncedLyrics,
+		ID3FrameLyricsTransliteration}
+	// tagID3ParseFrames : array containing every ID3 frame parsed when loading Tags without pictures
+	tagID3ParseFrames = []string{"Title", "Artist", "Album/Movie/Show title", "Genre", "Year",
+		"Track number/Position in set", "Comments", "Unsynchronised lyrics/text transcription",
+		"User defined text information frame", "TPE2", "TPOS", "TSRC", "TLEN", "WOAS", "WXXX", "UFID", "SYLT"}
 )
@@ -3,6 +3,8 @@ package youtube
 const (
 	// YouTubeVideoPrefix : YouTube video prefix
 	YouTubeVideoPrefix = "https://www.youtube.com"
+	// YouTubeVideoPattern : YouTube video URL parseable with *printf functions
+	YouTubeVideoPattern = YouTubeVideoPrefix + "/watch?v=%s"
 	// YouTubeQueryURL : YouTube query URL
 	YouTubeQueryURL = YouTubeVideoPrefix + "/results"
 	// YouTubeQueryPattern : YouTube query URL parseable with *printf functions
@@ -15,4 +17,31 @@ const (
 	YouTubeHTMLDurationSelector = ".accessible-description"
 	// YouTubeDurationTolerance : max video duration difference tolerance
 	YouTubeDurationTolerance = 20 // second(s)
+
+	// QueryStrategyDefault : query strategy using Track search pattern as-is
+	QueryStrategyDefault = "default"
+	// QueryStrategyAlbum : query strategy adding Track album to artist and song
+	QueryStrategyAlbum = "album"
+	// QueryStrategyOfficialAudio : query strategy looking for official audio uploads
+	QueryStrategyOfficialAudio = "official audio"
+	// QueryStrategyTopic : query strategy looking for YouTube auto-generated "Artist - Topic" uploads
+	QueryStrategyTopic = "topic"
+	// QueryStrategyFeaturings : query strategy adding Track featurings to artist and song
+	QueryStrategyFeaturings = "featurings"
+	// QueryStrategyTransliterated : query strategy using Latin transliteration of artist and song
+	QueryStrategyTransliterated = "transliterated"
+	// QueryStrategyMapping : strategy name given to results pulled out of a Mapping
+	QueryStrategyMapping = "mapping"
+	// QueryStrategyManual : strategy name given to manually input results
+	QueryStrategyManual = "manual"
+
+	// MappingAuthorAuto : Mapping author used for automatically taken decisions
+	MappingAuthorAuto = "spotitube"
+	// MappingTitle : title given to YouTube Track results pulled out of a Mapping
+	MappingTitle = "mapped video"
+	// MappingCSVSeparator : separator used to join rejected IDs into a single CSV field
+	MappingCSVSeparator = "|"
+
+	// ChannelAllowedScore : affinity score bonus given to results uploaded by allowed channels
+	ChannelAllowedScore = 20
 )
@@ -1,19 +1,49 @@
 package youtube
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"math"
+	"net/http"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	spttb_track "track"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/agnivade/levenshtein"
-	"github.com/kennygrant/sanitize"
+	"github.com/bradfitz/slice"
 )
 
-func pullTracksFromDoc(track spttb_track.Track, document *goquery.Document) (Tracks, error) {
+func queryDocument(pattern string) (*goquery.Document, error) {
+	var (
+		doc         *goquery.Document
+		queryString = fmt.Sprintf(YouTubeQueryPattern, url.QueryEscape(pattern))
+	)
+	request, _ := http.NewRequest("GET", queryString, nil)
+	request.Header.Add("Accept-Language", "en")
+	response, err := http.DefaultClient.Do(request)
+	if err == nil {
+		doc, _ = goquery.NewDocumentFromResponse(response)
+	} else {
+		doc, err = goquery.NewDocument(queryString)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Cannot retrieve doc from \"%s\": %s", queryString, err.Error())
+	}
+	html, _ := doc.Html()
+	if strings.Contains(strings.ToLower(html), "unusual traffic") {
+		return nil, fmt.Errorf("YouTube busted you: you'd better wait few minutes before retrying firing thousands video requests")
+	}
+	return doc, nil
+}
+
+func pullTracksFromDoc(track spttb_track.Track, document *goquery.Document, mapping Mapping, channels Channels) (Tracks, error) {
 	var (
 		tracks            = []Track{}
 		selection         = document.Find(YouTubeHTMLVideoSelector)
@@ -54,6 +84,9 @@ func pullTracksFromDoc(track spttb_track.Track, document *goquery.Document) (Tra
 		if itemHrefOk && itemTitleOk && itemLengthOk &&
 			(strings.Contains(strings.ToLower(itemHref), "youtu.be") || !strings.Contains(strings.ToLower(itemHref), "&list=")) &&
 			(strings.Contains(strings.ToLower(itemHref), "youtu.be") || strings.Contains(strings.ToLower(itemHref), "watch?v=")) {
+			if mapping.HasRejected(IDFromURL(YouTubeVideoPrefix+itemHref)) || channels.IsDenied(itemUser) {
+				continue
+			}
 			tracks = append(tracks, Track{
 				Track:    &track,
 				ID:       IDFromURL(YouTubeVideoPrefix + itemHref),
@@ -68,7 +101,39 @@ func pullTracksFromDoc(track spttb_track.Track, document *goquery.Document) (Tra
 	return tracks, nil
 }
 
-func (tracks Tracks) evaluateScores() Tracks {
+func queryCascade(track spttb_track.Track, queries []Query, channels Channels, search func(query Query) (Tracks, error)) (Tracks, error) {
+	var (
+		tracks     Tracks
+		tracksSeen = make(map[string]bool)
+	)
+	for queryIndex, query := range queries {
+		queryTracks, err := search(query)
+		if err != nil && queryIndex == 0 {
+			return Tracks{}, err
+		} else if err != nil {
+			continue
+		}
+
+		var queryMatch bool
+		for _, queryTrack := range queryTracks.evaluateScores(channels) {
+			if tracksSeen[queryTrack.ID] {
+				continue
+			}
+			tracksSeen[queryTrack.ID] = true
+			queryTrack.Strategy = query.Strategy
+			if queryTrack.Match(track) == nil {
+				queryMatch = true
+			}
+			tracks = append(tracks, queryTrack)
+		}
+		if queryMatch {
+			break
+		}
+	}
+	return tracks, nil
+}
+
+func (tracks Tracks) evaluateScores(channels Channels) Tracks {
 	var evaluatedTracks Tracks
 	for _, track := range tracks {
 		if math.Abs(float64(track.Track.Duration-track.Duration)) <= float64(YouTubeDurationTolerance/2) {
@@ -79,15 +144,152 @@ func (tracks Tracks) evaluateScores() Tracks {
 		if err := track.Track.SeemsByWordMatch(fmt.Sprintf("%s %s", track.User, track.Title)); err == nil {
 			track.AffinityScore += 10
 		}
-		if strings.Contains(sanitize.Name(track.User), sanitize.Name(track.Track.Artist)) {
+		if spttb_track.SeemsContained(track.User, track.Track.Artist) {
 			track.AffinityScore += 10
 		}
-		if spttb_track.SeemsType(track.Title, track.Track.SongType) {
+		if len(track.Track.Versions) > 0 && track.Track.SeemsVersion(track.Title) == nil {
 			track.AffinityScore += 10
 		}
+		if channels.IsAllowed(track.User) {
+			track.AffinityScore += ChannelAllowedScore
+		}
 		levenshteinDistance := levenshtein.ComputeDistance(track.Track.SearchPattern, fmt.Sprintf("%s %s", track.User, track.Title))
 		track.AffinityScore -= levenshteinDistance
 		evaluatedTracks = append(evaluatedTracks, track)
 	}
 	return evaluatedTracks
 }
+
+func channelKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (mapping Mapping) youTubeTrack(track *spttb_track.Track) Track {
+	return Track{
+		Track:    track,
+		ID:       mapping.VideoID,
+		URL:      URLFromID(mapping.VideoID),
+		Title:    MappingTitle,
+		User:     mapping.Author,
+		Duration: track.Duration,
+		Strategy: QueryStrategyMapping,
+		Mapped:   true,
+	}
+}
+
+func (mappings Mappings) merge(mapping Mapping) {
+	if len(mapping.SpotifyID) == 0 {
+		return
+	}
+	current, ok := mappings[mapping.SpotifyID]
+	if !ok {
+		mappings[mapping.SpotifyID] = mapping
+		return
+	}
+	for _, rejectedID := range mapping.Rejected {
+		if !current.HasRejected(rejectedID) {
+			current.Rejected = append(current.Rejected, rejectedID)
+		}
+	}
+	if mapping.Time.After(current.Time) {
+		current.VideoID = mapping.VideoID
+		current.Strategy = mapping.Strategy
+		current.Author = mapping.Author
+		current.Time = mapping.Time
+	}
+	if current.HasRejected(current.VideoID) {
+		current.VideoID = ""
+	}
+	mappings[mapping.SpotifyID] = current
+}
+
+func (mappings Mappings) sorted() []Mapping {
+	var sorted []Mapping
+	for _, mapping := range mappings {
+		sorted = append(sorted, mapping)
+	}
+	slice.Sort(sorted[:], func(i, j int) bool {
+		return sorted[i].SpotifyID < sorted[j].SpotifyID
+	})
+	return sorted
+}
+
+func (mappings Mappings) exportJSON(path string) error {
+	mappingsJSON, err := json.MarshalIndent(mappings.sorted(), "", "\t")
+	if err != nil {
+		return fmt.Errorf("Unable to marshal mappings: %s", err.Error())
+	}
+	return ioutil.WriteFile(path, mappingsJSON, 0644)
+}
+
+func (mappings Mappings) exportCSV(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"spotify_id", "video_id", "rejected", "author", "time", "strategy"})
+	for _, mapping := range mappings.sorted() {
+		writer.Write([]string{mapping.SpotifyID, mapping.VideoID,
+			strings.Join(mapping.Rejected, MappingCSVSeparator),
+			mapping.Author, mapping.Time.Format(time.RFC3339), mapping.Strategy})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func importJSON(path string) ([]Mapping, error) {
+	var imported []Mapping
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return imported, err
+	}
+	if err := json.Unmarshal(content, &imported); err != nil {
+		return imported, fmt.Errorf("Unable to parse mappings from %s: %s", path, err.Error())
+	}
+	return imported, nil
+}
+
+func importCSV(path string) ([]Mapping, error) {
+	var imported []Mapping
+	file, err := os.Open(path)
+	if err != nil {
+		return imported, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return imported, fmt.Errorf("Unable to parse mappings from %s: %s", path, err.Error())
+	}
+	for recordIndex, record := range records {
+		if recordIndex == 0 && len(record) > 0 && record[0] == "spotify_id" {
+			continue
+		}
+		if len(record) < 5 {
+			return imported, fmt.Errorf("Malformed mapping at line %d: expected 5 columns, given %d", recordIndex+1, len(record))
+		}
+		mapping := Mapping{
+			SpotifyID: strings.TrimSpace(record[0]),
+			VideoID:   strings.TrimSpace(record[1]),
+			Author:    strings.TrimSpace(record[3]),
+		}
+		for _, rejectedID := range strings.Split(record[2], MappingCSVSeparator) {
+			if rejectedID = strings.TrimSpace(rejectedID); len(rejectedID) > 0 {
+				mapping.Rejected = append(mapping.Rejected, rejectedID)
+			}
+		}
+		if mappingTime, timeErr := time.Parse(time.RFC3339, strings.TrimSpace(record[4])); timeErr == nil {
+			mapping.Time = mappingTime
+		}
+		if len(record) > 5 {
+			mapping.Strategy = strings.TrimSpace(record[5])
+		}
+		imported = append(imported, mapping)
+	}
+	return imported, nil
+}
@@ -0,0 +1,223 @@
+package youtube
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	spttb_track "track"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestQueryCascade(t *testing.T) {
+	track := spttb_track.Track{Artist: "Daft Punk", Song: "Get Lucky", Title: "Get Lucky", Duration: 248, SearchPattern: "Daft Punk Get Lucky"}
+	queries := Queries(track)
+	for _, fixture := range []struct {
+		results          map[string]Tracks
+		errors           map[string]error
+		expectedSearched []string
+		expectedIDs      []string
+		expectedErr      bool
+	}{
+		{
+			results: map[string]Tracks{
+				QueryStrategyDefault: {{Track: &track, ID: "match", Title: "Daft Punk - Get Lucky", Duration: 248}},
+			},
+			expectedSearched: []string{QueryStrategyDefault},
+			expectedIDs:      []string{"match"},
+		},
+		{
+			results: map[string]Tracks{
+				QueryStrategyDefault:       {{Track: &track, ID: "long", Title: "Daft Punk - Get Lucky", Duration: 600}},
+				QueryStrategyOfficialAudio: {{Track: &track, ID: "long", Title: "Daft Punk - Get Lucky", Duration: 600}, {Track: &track, ID: "match", Title: "Daft Punk - Get Lucky", Duration: 250}},
+			},
+			expectedSearched: []string{QueryStrategyDefault, QueryStrategyOfficialAudio},
+			expectedIDs:      []string{"long", "match"},
+		},
+		{
+			errors:           map[string]error{QueryStrategyOfficialAudio: fmt.Errorf("unreachable")},
+			expectedSearched: []string{QueryStrategyDefault, QueryStrategyOfficialAudio, QueryStrategyTopic},
+		},
+		{
+			errors:           map[string]error{QueryStrategyDefault: fmt.Errorf("unreachable")},
+			expectedSearched: []string{QueryStrategyDefault},
+			expectedErr:      true,
+		},
+	} {
+		var searched []string
+		tracks, err := queryCascade(track, queries, Channels{}, func(query Query) (Tracks, error) {
+			searched = append(searched, query.Strategy)
+			return fixture.results[query.Strategy], fixture.errors[query.Strategy]
+		})
+		if (err != nil) != fixture.expectedErr {
+			t.Errorf("queryCascade() error = %v, expected error: %v", err, fixture.expectedErr)
+		}
+		if fmt.Sprint(searched) != fmt.Sprint(fixture.expectedSearched) {
+			t.Errorf("queryCascade() searched %v, expected %v", searched, fixture.expectedSearched)
+		}
+		var ids []string
+		for _, track := range tracks {
+			ids = append(ids, track.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(fixture.expectedIDs) {
+			t.Errorf("queryCascade() results %v, expected %v", ids, fixture.expectedIDs)
+		}
+	}
+}
+
+func TestPullTracksFromDoc(t *testing.T) {
+	page, err := os.Open(filepath.Join("testdata", "search_results.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer page.Close()
+	document, err := goquery.NewDocumentFromReader(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	track := spttb_track.Track{Artist: "Daft Punk", Song: "Get Lucky", Title: "Get Lucky", Duration: 248}
+	for _, fixture := range []struct {
+		mapping     Mapping
+		channels    Channels
+		expectedIDs []string
+	}{
+		{Mapping{}, Channels{}, []string{"5NV6Rdv1a3I", "h5EofwRzit0", "KaRaOkEvErS", "ReJeCtEdViD"}},
+		{Mapping{}, Channels{"karaoke hits": false}, []string{"5NV6Rdv1a3I", "h5EofwRzit0", "ReJeCtEdViD"}},
+		{Mapping{Rejected: []string{"ReJeCtEdViD"}}, Channels{"karaoke hits": false, "daft punk": true}, []string{"5NV6Rdv1a3I", "h5EofwRzit0"}},
+	} {
+		tracks, err := pullTracksFromDoc(track, document, fixture.mapping, fixture.channels)
+		if err != nil {
+			t.Fatalf("pullTracksFromDoc() unexpected error: %s", err.Error())
+		}
+		var ids []string
+		for _, track := range tracks {
+			ids = append(ids, track.ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(fixture.expectedIDs) {
+			t.Errorf("pullTracksFromDoc() results %v, expected %v", ids, fixture.expectedIDs)
+		}
+	}
+}
+
+func TestEvaluateScoresAllowedChannel(t *testing.T) {
+	track := spttb_track.Track{Artist: "Daft Punk", Song: "Get Lucky", Title: "Get Lucky", Duration: 248, SearchPattern: "Daft Punk Get Lucky"}
+	tracks := Tracks{
+		{Track: &track, ID: "lyrics", Title: "Daft Punk - Get Lucky (Lyrics)", User: "Lyrics Channel", Duration: 250},
+	}
+	neutral := tracks.evaluateScores(Channels{})
+	allowed := tracks.evaluateScores(Channels{"lyrics channel": true})
+	denied := tracks.evaluateScores(Channels{"lyrics channel": false})
+	if allowed[0].AffinityScore != neutral[0].AffinityScore+ChannelAllowedScore {
+		t.Errorf("allowed channel score = %d, expected %d", allowed[0].AffinityScore, neutral[0].AffinityScore+ChannelAllowedScore)
+	}
+	if denied[0].AffinityScore != neutral[0].AffinityScore {
+		t.Errorf("denied channel score = %d, expected not to get any bonus (%d)", denied[0].AffinityScore, neutral[0].AffinityScore)
+	}
+}
+
+func TestMappingsMerge(t *testing.T) {
+	var (
+		older = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		newer = older.Add(time.Hour)
+	)
+	for _, fixture := range []struct {
+		current  Mapping
+		merged   Mapping
+		expected Mapping
+	}{
+		{
+			current:  Mapping{SpotifyID: "track", VideoID: "old", Author: MappingAuthorAuto, Time: older},
+			merged:   Mapping{SpotifyID: "track", VideoID: "new", Strategy: QueryStrategyManual, Author: "user", Time: newer},
+			expected: Mapping{SpotifyID: "track", VideoID: "new", Strategy: QueryStrategyManual, Author: "user", Time: newer},
+		},
+		{
+			current:  Mapping{SpotifyID: "track", VideoID: "new", Author: "user", Time: newer},
+			merged:   Mapping{SpotifyID: "track", VideoID: "old", Author: MappingAuthorAuto, Time: older},
+			expected: Mapping{SpotifyID: "track", VideoID: "new", Author: "user", Time: newer},
+		},
+		{
+			current:  Mapping{SpotifyID: "track", VideoID: "picked", Rejected: []string{"first"}, Time: older},
+			merged:   Mapping{SpotifyID: "track", Rejected: []string{"first", "second"}, Time: older},
+			expected: Mapping{SpotifyID: "track", VideoID: "picked", Rejected: []string{"first", "second"}, Time: older},
+		},
+		{
+			current:  Mapping{SpotifyID: "track", VideoID: "picked", Time: newer},
+			merged:   Mapping{SpotifyID: "track", Rejected: []string{"picked"}, Time: older},
+			expected: Mapping{SpotifyID: "track", Rejected: []string{"picked"}, Time: newer},
+		},
+	} {
+		mappings := Mappings{fixture.current.SpotifyID: fixture.current}
+		mappings.merge(fixture.merged)
+		if !reflect.DeepEqual(mappings[fixture.current.SpotifyID], fixture.expected) {
+			t.Errorf("merge(%+v) = %+v, expected %+v", fixture.merged, mappings[fixture.current.SpotifyID], fixture.expected)
+		}
+	}
+}
+
+func TestMappingsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mappings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mappings := Mappings{
+		"first":  {SpotifyID: "first", VideoID: "video", Strategy: QueryStrategyTopic, Author: MappingAuthorAuto, Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)},
+		"second": {SpotifyID: "second", Rejected: []string{"bad", "worse"}, Author: "user", Time: time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, fixture := range []struct {
+		path   string
+		export func(string) error
+		load   func(string) ([]Mapping, error)
+	}{
+		{filepath.Join(dir, "mappings.csv"), mappings.exportCSV, importCSV},
+		{filepath.Join(dir, "mappings.json"), mappings.exportJSON, importJSON},
+	} {
+		if err := fixture.export(fixture.path); err != nil {
+			t.Fatalf("unable to export %s: %s", fixture.path, err.Error())
+		}
+		imported, err := fixture.load(fixture.path)
+		if err != nil {
+			t.Fatalf("unable to import %s: %s", fixture.path, err.Error())
+		}
+		if !reflect.DeepEqual(imported, mappings.sorted()) {
+			t.Errorf("%s imported %+v, expected %+v", filepath.Base(fixture.path), imported, mappings.sorted())
+		}
+	}
+}
+
+func TestMappingsImportLegacyCSV(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mappings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "mappings.csv")
+	if err := ioutil.WriteFile(path, []byte("spotify_id,video_id,rejected,author,time\n"+
+		"first,video,bad"+MappingCSVSeparator+"worse,user,2020-01-01T00:00:00Z\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := importCSV(path)
+	if err != nil {
+		t.Fatalf("legacy CSV expected to be imported: %s", err.Error())
+	}
+	expected := []Mapping{{SpotifyID: "first", VideoID: "video", Rejected: []string{"bad", "worse"},
+		Author: "user", Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	if !reflect.DeepEqual(imported, expected) {
+		t.Errorf("legacy CSV imported %+v, expected %+v", imported, expected)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("first,video,bad\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := importCSV(path); err == nil {
+		t.Errorf("CSV with missing columns expected to be rejected")
+	}
+}
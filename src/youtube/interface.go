@@ -3,46 +3,40 @@ package youtube
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"math"
-	"net/http"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
+	spttb_system "system"
 	spttb_track "track"
 
-	"github.com/PuerkitoBio/goquery"
 	"github.com/bradfitz/slice"
 )
 
-// QueryTracks : initialize a Tracks object by searching for Track results
-func QueryTracks(track *spttb_track.Track) (Tracks, error) {
-	var (
-		doc         *goquery.Document
-		queryString = fmt.Sprintf(YouTubeQueryPattern,
-			strings.Replace(track.SearchPattern, " ", "+", -1))
-	)
-	request, _ := http.NewRequest("GET", queryString, nil)
-	request.Header.Add("Accept-Language", "en")
-	response, err := http.DefaultClient.Do(request)
-	if err == nil {
-		doc, _ = goquery.NewDocumentFromResponse(response)
-	} else {
-		doc, err = goquery.NewDocument(queryString)
-	}
-	if err != nil {
-		return Tracks{}, fmt.Errorf(fmt.Sprintf("Cannot retrieve doc from \"%s\": %s", queryString, err.Error()))
-	}
-	html, _ := doc.Html()
-	if strings.Contains(strings.ToLower(html), "unusual traffic") {
-		return Tracks{}, fmt.Errorf("YouTube busted you: you'd better wait few minutes before retrying firing thousands video requests")
+// QueryTracks : initialize a Tracks object by searching for Track results, consulting input Mappings first
+// and filtering out blacklisted videos and denied Channels: query strategies get run in cascade until
+// a result matching with Track is found
+func QueryTracks(track *spttb_track.Track, mappings Mappings, channels Channels) (Tracks, error) {
+	mapping := mappings[track.SpotifyID]
+	if len(mapping.VideoID) > 0 {
+		return Tracks{mapping.youTubeTrack(track)}, nil
 	}
 
-	tracks, err := pullTracksFromDoc(*track, doc)
+	tracks, err := queryCascade(*track, Queries(*track), channels, func(query Query) (Tracks, error) {
+		doc, err := queryDocument(query.Pattern)
+		if err != nil {
+			return Tracks{}, err
+		}
+		return pullTracksFromDoc(*track, doc, mapping, channels)
+	})
 	if err != nil {
 		return Tracks{}, err
 	}
 
-	tracks = tracks.evaluateScores()
 	slice.Sort(tracks[:], func(i, j int) bool {
 		var iPlus, jPlus int
 		if tracks[i].AffinityScore == tracks[j].AffinityScore {
@@ -58,8 +52,46 @@ func QueryTracks(track *spttb_track.Track) (Tracks, error) {
 	return tracks, nil
 }
 
+// Queries : return the cascade of Query objects to be used to search for input Track
+func Queries(track spttb_track.Track) []Query {
+	var (
+		queries     []Query
+		queriesSeen = make(map[string]bool)
+		artistSong  = fmt.Sprintf("%s %s", track.Artist, track.Song)
+	)
+	for _, query := range []Query{
+		Query{Strategy: QueryStrategyDefault, Pattern: track.SearchPattern},
+		Query{Strategy: QueryStrategyAlbum, Pattern: func() string {
+			if len(track.Album) == 0 || strings.EqualFold(track.Album, track.Song) {
+				return ""
+			}
+			return fmt.Sprintf("%s %s", artistSong, track.Album)
+		}()},
+		Query{Strategy: QueryStrategyOfficialAudio, Pattern: fmt.Sprintf("%s official audio", artistSong)},
+		Query{Strategy: QueryStrategyTopic, Pattern: fmt.Sprintf("%s topic %s", track.Artist, track.Song)},
+		Query{Strategy: QueryStrategyFeaturings, Pattern: func() string {
+			if len(track.Featurings) == 0 {
+				return ""
+			}
+			return fmt.Sprintf("%s %s %s", track.Artist, strings.Join(track.Featurings, " "), track.Song)
+		}()},
+		Query{Strategy: QueryStrategyTransliterated, Pattern: spttb_track.Transliterate(artistSong)},
+	} {
+		query.Pattern = strings.Join(strings.Fields(query.Pattern), " ")
+		if len(query.Pattern) == 0 || queriesSeen[strings.ToLower(query.Pattern)] {
+			continue
+		}
+		queriesSeen[strings.ToLower(query.Pattern)] = true
+		queries = append(queries, query)
+	}
+	return queries
+}
+
 // Match : return nil error if YouTube Track result object is matching with input Track object
 func (youtube_track Track) Match(track spttb_track.Track) error {
+	if youtube_track.Mapped {
+		return nil
+	}
 	if int(math.Abs(float64(track.Duration-youtube_track.Duration))) > YouTubeDurationTolerance {
 		return fmt.Errorf(fmt.Sprintf("The duration difference is excessive: | %d - %d | = %d (max tolerated: %d)",
 			track.Duration, youtube_track.Duration, int(math.Abs(float64(track.Duration-youtube_track.Duration))), YouTubeDurationTolerance))
@@ -73,17 +105,25 @@ func (youtube_track Track) Match(track spttb_track.Track) error {
 	return track.Seems(fmt.Sprintf("%s %s", youtube_track.User, youtube_track.Title))
 }
 
-// Download : delegate youtube-dl call to download YouTube Track result
+// Download : delegate youtube-dl call to download YouTube Track result native audio stream, with no transcoding,
+// unless the very same stream has already been downloaded (e.g. resumed from a stale workspace)
 func (youtube_track Track) Download() error {
+	if origin, err := ioutil.ReadFile(youtube_track.Track.FilenameStreamOrigin()); err == nil &&
+		string(origin) == youtube_track.URL && spttb_system.FileExists(youtube_track.Track.FilenameStream()) {
+		return nil
+	}
+	os.Remove(youtube_track.Track.FilenameStream())
+	os.Remove(youtube_track.Track.FilenameStreamOrigin())
+
 	var commandOut bytes.Buffer
 	commandCmd := "youtube-dl"
-	commandArgs := []string{"--output", fmt.Sprintf("%s.%s", youtube_track.Track.FilenameTemp, youtube_track.Track.FilenameExt[1:]), "--format", "bestaudio", "--extract-audio", "--audio-format", youtube_track.Track.FilenameExt[1:], "--audio-quality", "0", youtube_track.URL}
+	commandArgs := []string{"--output", youtube_track.Track.FilenameStream(), "--format", "bestaudio", youtube_track.URL}
 	commandObj := exec.Command(commandCmd, commandArgs...)
 	commandObj.Stderr = &commandOut
 	if commandErr := commandObj.Run(); commandErr != nil {
 		return fmt.Errorf(fmt.Sprintf("Something went wrong while executing \"%s %s\":\n%s", commandCmd, strings.Join(commandArgs, " "), commandOut.String()))
 	}
-	return nil
+	return ioutil.WriteFile(youtube_track.Track.FilenameStreamOrigin(), []byte(youtube_track.URL), 0644)
 }
 
 // IDFromURL : extract YouTube entry ID from input URL
@@ -103,6 +143,11 @@ func IDFromURL(url string) string {
 	return idPart
 }
 
+// URLFromID : compose YouTube video URL from input entry ID
+func URLFromID(id string) string {
+	return fmt.Sprintf(YouTubeVideoPattern, id)
+}
+
 // ValidateURL : return nil error if input URL is a valid YouTube URL
 func ValidateURL(url string) error {
 	if !strings.Contains(strings.ToLower(url), "youtu.be/") &&
@@ -111,3 +156,128 @@ func ValidateURL(url string) error {
 	}
 	return nil
 }
+
+// Pick : keep track of input videoID, found using input strategy, as the one chosen by author for input spotifyID
+func (mappings Mappings) Pick(spotifyID string, videoID string, strategy string, author string) {
+	mapping := mappings[spotifyID]
+	mapping.SpotifyID = spotifyID
+	mapping.VideoID = videoID
+	mapping.Strategy = strategy
+	mapping.Author = author
+	mapping.Time = time.Now()
+	mappings[spotifyID] = mapping
+}
+
+// Reject : keep track of input videoID as rejected by author for input spotifyID
+func (mappings Mappings) Reject(spotifyID string, videoID string, author string) {
+	mapping := mappings[spotifyID]
+	mapping.SpotifyID = spotifyID
+	if mapping.VideoID == videoID {
+		mapping.VideoID = ""
+		mapping.Author = author
+		mapping.Time = time.Now()
+	}
+	if !mapping.HasRejected(videoID) {
+		mapping.Rejected = append(mapping.Rejected, videoID)
+	}
+	mappings[spotifyID] = mapping
+}
+
+// Unreject : drop input videoID from the ones rejected for input spotifyID
+func (mappings Mappings) Unreject(spotifyID string, videoID string) {
+	mapping, ok := mappings[spotifyID]
+	if !ok {
+		return
+	}
+	var rejected []string
+	for _, rejectedID := range mapping.Rejected {
+		if rejectedID != videoID {
+			rejected = append(rejected, rejectedID)
+		}
+	}
+	mapping.Rejected = rejected
+	mappings[spotifyID] = mapping
+}
+
+// HasRejected : return True if input videoID has been rejected for Mapping Spotify track
+func (mapping Mapping) HasRejected(videoID string) bool {
+	for _, rejectedID := range mapping.Rejected {
+		if rejectedID == videoID {
+			return true
+		}
+	}
+	return false
+}
+
+// Curated : return Mappings copy dropping automatically chosen videos, but keeping every rejected one
+func (mappings Mappings) Curated() Mappings {
+	var curated = Mappings{}
+	for spotifyID, mapping := range mappings {
+		if mapping.Author == MappingAuthorAuto {
+			mapping.VideoID = ""
+		}
+		curated[spotifyID] = mapping
+	}
+	return curated
+}
+
+// Export : dump Mappings to input path, using JSON or CSV format depending on its extension
+func (mappings Mappings) Export(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return mappings.exportJSON(path)
+	case ".csv":
+		return mappings.exportCSV(path)
+	}
+	return fmt.Errorf("Unsupported mappings format: %s (expected .json or .csv)", filepath.Ext(path))
+}
+
+// Import : merge JSON or CSV Mappings from input path, returning the number of imported entries
+func (mappings Mappings) Import(path string) (int, error) {
+	var (
+		imported    []Mapping
+		importedErr error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		imported, importedErr = importJSON(path)
+	case ".csv":
+		imported, importedErr = importCSV(path)
+	default:
+		return 0, fmt.Errorf("Unsupported mappings format: %s (expected .json or .csv)", filepath.Ext(path))
+	}
+	if importedErr != nil {
+		return 0, importedErr
+	}
+	for _, mapping := range imported {
+		mappings.merge(mapping)
+	}
+	return len(imported), nil
+}
+
+// Allow : mark input channel name as allowed, boosting its uploads
+func (channels Channels) Allow(name string) {
+	channels[channelKey(name)] = true
+}
+
+// Deny : mark input channel name as denied, excluding its uploads
+func (channels Channels) Deny(name string) {
+	channels[channelKey(name)] = false
+}
+
+// Forget : drop any preference about input channel name
+func (channels Channels) Forget(name string) {
+	delete(channels, channelKey(name))
+}
+
+// IsAllowed : return True if input channel name has been allowed
+func (channels Channels) IsAllowed(name string) bool {
+	allowed, ok := channels[channelKey(name)]
+	return ok && allowed
+}
+
+// IsDenied : return True if input channel name has been denied
+func (channels Channels) IsDenied(name string) bool {
+	allowed, ok := channels[channelKey(name)]
+	return ok && !allowed
+}
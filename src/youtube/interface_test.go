@@ -0,0 +1,85 @@
+package youtube
+
+import (
+	"testing"
+
+	spttb_track "track"
+)
+
+func TestQueries(t *testing.T) {
+	for _, fixture := range []struct {
+		track    spttb_track.Track
+		expected []Query
+	}{
+		{
+			spttb_track.Track{Artist: "Daft Punk", Song: "Get Lucky", Album: "Random Access Memories",
+				Featurings: []string{"Pharrell Williams"}, SearchPattern: "Daft Punk - Get Lucky feat Pharrell Williams"},
+			[]Query{
+				{QueryStrategyDefault, "Daft Punk - Get Lucky feat Pharrell Williams"},
+				{QueryStrategyAlbum, "Daft Punk Get Lucky Random Access Memories"},
+				{QueryStrategyOfficialAudio, "Daft Punk Get Lucky official audio"},
+				{QueryStrategyTopic, "Daft Punk topic Get Lucky"},
+				{QueryStrategyFeaturings, "Daft Punk Pharrell Williams Get Lucky"},
+				{QueryStrategyTransliterated, "daft punk get lucky"},
+			},
+		},
+		{
+			spttb_track.Track{Artist: "Beyonce", Song: "Halo", Album: "Halo", SearchPattern: "Beyonce Halo"},
+			[]Query{
+				{QueryStrategyDefault, "Beyonce Halo"},
+				{QueryStrategyOfficialAudio, "Beyonce Halo official audio"},
+				{QueryStrategyTopic, "Beyonce topic Halo"},
+			},
+		},
+		{
+			spttb_track.Track{Artist: "宇多田ヒカル", Song: "First Love", SearchPattern: "宇多田ヒカル First Love"},
+			[]Query{
+				{QueryStrategyDefault, "宇多田ヒカル First Love"},
+				{QueryStrategyOfficialAudio, "宇多田ヒカル First Love official audio"},
+				{QueryStrategyTopic, "宇多田ヒカル topic First Love"},
+				{QueryStrategyTransliterated, spttb_track.Transliterate("宇多田ヒカル First Love")},
+			},
+		},
+	} {
+		queries := Queries(fixture.track)
+		if len(queries) != len(fixture.expected) {
+			t.Errorf("Queries(%s) = %v, expected %v", fixture.track.SearchPattern, queries, fixture.expected)
+			continue
+		}
+		for index, query := range queries {
+			if query != fixture.expected[index] {
+				t.Errorf("Queries(%s)[%d] = %v, expected %v", fixture.track.SearchPattern, index, query, fixture.expected[index])
+			}
+		}
+	}
+}
+
+func TestChannels(t *testing.T) {
+	channels := Channels{}
+	channels.Allow(" Daft Punk ")
+	channels.Deny("Karaoke Hits")
+	for _, fixture := range []struct {
+		name    string
+		allowed bool
+		denied  bool
+	}{
+		{"daft punk", true, false},
+		{"DAFT PUNK", true, false},
+		{"karaoke hits", false, true},
+		{"Lyrics Channel", false, false},
+	} {
+		if channels.IsAllowed(fixture.name) != fixture.allowed || channels.IsDenied(fixture.name) != fixture.denied {
+			t.Errorf("channel %q allowed: %t, denied: %t, expected allowed: %t, denied: %t", fixture.name,
+				channels.IsAllowed(fixture.name), channels.IsDenied(fixture.name), fixture.allowed, fixture.denied)
+		}
+	}
+
+	channels.Allow("karaoke hits")
+	if !channels.IsAllowed("Karaoke Hits") || channels.IsDenied("Karaoke Hits") {
+		t.Errorf("denied channel expected to be allowed once asked to")
+	}
+	channels.Forget("DAFT PUNK")
+	if channels.IsAllowed("Daft Punk") || channels.IsDenied("Daft Punk") || len(channels) != 1 {
+		t.Errorf("forgotten channel expected to lose any preference, got %v", channels)
+	}
+}
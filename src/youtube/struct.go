@@ -1,6 +1,8 @@
 package youtube
 
 import (
+	"time"
+
 	spttb_track "track"
 )
 
@@ -16,4 +18,28 @@ type Track struct {
 	User          string
 	Duration      int
 	AffinityScore int
+	Strategy      string
+	Mapped        bool
+}
+
+// Query : struct containing a YouTube search pattern, along with the name of the strategy used to build it
+type Query struct {
+	Strategy string
+	Pattern  string
 }
+
+// Mapping : struct keeping track of the YouTube video chosen - and the ones rejected - for a Spotify track
+type Mapping struct {
+	SpotifyID string    `json:"spotify_id"`
+	VideoID   string    `json:"video_id"`
+	Rejected  []string  `json:"rejected"`
+	Strategy  string    `json:"strategy"`
+	Author    string    `json:"author"`
+	Time      time.Time `json:"time"`
+}
+
+// Mappings : Spotify ID - Mapping association
+type Mappings map[string]Mapping
+
+// Channels : YouTube channel name - preference association (true if allowed, false if denied)
+type Channels map[string]bool